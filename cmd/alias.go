@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mskelton/pool/internal/config"
+	"github.com/mskelton/pool/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+var aliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "Manage branch and command aliases",
+	Long: `Manage aliases that expand to a branch name or to a full command line.
+
+An alias is expanded when it is used in place of a command, so with
+"hot" set to "release/2024.10", "pool hot" opens the worktree for that
+branch, and with "st" set to "status", "pool st" shows the pool status.
+Aliases may refer to other aliases, but never to themselves, and built-in
+commands always take precedence over aliases.`,
+}
+
+var aliasAddCmd = &cobra.Command{
+	Use:   "add <name> <expansion>...",
+	Short: "Add or replace an alias",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := addAlias(args[0], strings.Join(args[1:], " ")); err != nil {
+			exitWithError(cmd, err)
+		}
+	},
+}
+
+var aliasListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List aliases",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		listAliases()
+	},
+}
+
+var aliasRmCmd = &cobra.Command{
+	Use:               "rm <name>",
+	Aliases:           []string{"remove"},
+	Short:             "Remove an alias",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeAliases,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := removeAlias(args[0]); err != nil {
+			exitWithError(cmd, err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(aliasCmd)
+	aliasCmd.AddCommand(aliasAddCmd)
+	aliasCmd.AddCommand(aliasListCmd)
+	aliasCmd.AddCommand(aliasRmCmd)
+
+	// Flags after the alias name belong to its expansion.
+	aliasAddCmd.Flags().SetInterspersed(false)
+
+	aliasCmd.PersistentFlags().BoolVarP(&global, "global", "g", false, "Use global configuration")
+}
+
+func addAlias(name, expansion string) error {
+	if isBuiltinCommand(name) {
+		return fmt.Errorf("%q is a built-in command and cannot be used as an alias", name)
+	}
+
+	if err := config.ValidateAlias(name, expansion); err != nil {
+		return err
+	}
+
+	aliases := make(map[string]string)
+	for k, v := range cfg.Aliases {
+		aliases[k] = v
+	}
+	aliases[name] = expansion
+
+	if _, err := expandAliases([]string{name}, aliases); err != nil {
+		return err
+	}
+
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+
+	fileConfig, err := config.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if fileConfig.Aliases == nil {
+		fileConfig.Aliases = make(map[string]string)
+	}
+	fileConfig.Aliases[name] = expansion
+
+	if err := fileConfig.Save(path); err != nil {
+		return err
+	}
+
+	logger.Success("Added alias %s = %s", name, expansion)
+	return nil
+}
+
+func listAliases() {
+	if len(cfg.Aliases) == 0 {
+		logger.Info("No aliases configured")
+		return
+	}
+
+	names := make([]string, 0, len(cfg.Aliases))
+	for name := range cfg.Aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if isBuiltinCommand(name) {
+			fmt.Printf("%s = %s (ignored, shadowed by built-in command)\n", name, cfg.Aliases[name])
+		} else {
+			fmt.Printf("%s = %s\n", name, cfg.Aliases[name])
+		}
+	}
+}
+
+func removeAlias(name string) error {
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+
+	fileConfig, err := config.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := fileConfig.Aliases[name]; !ok {
+		return fmt.Errorf("alias %q is not defined in %s", name, path)
+	}
+
+	delete(fileConfig.Aliases, name)
+	if err := fileConfig.Save(path); err != nil {
+		return err
+	}
+
+	logger.Success("Removed alias %s", name)
+	return nil
+}
+
+// expandAliases replaces the command word in args with its alias until it no
+// longer names one, so aliases may refer to other aliases. Built-in commands
+// are never expanded, and an alias that expands back to itself is an error.
+func expandAliases(args []string, aliases map[string]string) ([]string, error) {
+	var chain []string
+
+	for {
+		i := commandIndex(args)
+		if i < 0 {
+			return args, nil
+		}
+
+		name := args[i]
+		expansion, ok := aliases[name]
+		if !ok {
+			return args, nil
+		}
+
+		if isBuiltinCommand(name) {
+			if len(chain) == 0 {
+				logger.Warning("Ignoring alias %q, it is shadowed by the built-in command", name)
+			}
+			return args, nil
+		}
+
+		if slices.Contains(chain, name) {
+			return nil, fmt.Errorf("recursive alias expansion: %s -> %s", strings.Join(chain, " -> "), name)
+		}
+		chain = append(chain, name)
+
+		expanded := append([]string{}, args[:i]...)
+		expanded = append(expanded, strings.Fields(expansion)...)
+		args = append(expanded, args[i+1:]...)
+	}
+}
+
+// commandIndex returns the index of the first positional argument, skipping
+// root flags and their values.
+func commandIndex(args []string) int {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == "--" {
+			if i+1 < len(args) {
+				return i + 1
+			}
+			return -1
+		}
+
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			return i
+		}
+
+		if strings.Contains(arg, "=") {
+			continue
+		}
+
+		if name, ok := strings.CutPrefix(arg, "--"); ok {
+			if flag := rootCmd.PersistentFlags().Lookup(name); flag != nil && flag.NoOptDefVal == "" {
+				i++
+			}
+		} else if len(arg) == 2 {
+			if flag := rootCmd.PersistentFlags().ShorthandLookup(arg[1:]); flag != nil && flag.NoOptDefVal == "" {
+				i++
+			}
+		}
+	}
+
+	return -1
+}
+
+func isBuiltinCommand(name string) bool {
+	if name == cobra.ShellCompRequestCmd || name == cobra.ShellCompNoDescRequestCmd {
+		return true
+	}
+
+	rootCmd.InitDefaultHelpCmd()
+	rootCmd.InitDefaultCompletionCmd()
+
+	for _, cmd := range rootCmd.Commands() {
+		if cmd.Name() == name || slices.Contains(cmd.Aliases, name) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -5,18 +5,36 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/mskelton/pool/internal/errors"
 	"github.com/mskelton/pool/internal/git"
+	"github.com/mskelton/pool/internal/hooks"
 	"github.com/mskelton/pool/internal/logger"
 	"github.com/mskelton/pool/internal/pool"
 	"github.com/spf13/cobra"
 )
 
 var (
-	dryRun bool
+	dryRun          bool
+	includeUnpushed bool
+	includeLocked   bool
+	olderThan       string
+	activityBy      string
+	keepRecent      int
 )
 
+var activityKinds = []string{"commit", "modified", "switched"}
+
+var activityLabels = map[string]string{
+	"commit":   "last commit",
+	"modified": "last modified",
+	"switched": "last switched into",
+}
+
 var cleanCmd = &cobra.Command{
 	Use:     "clean [type]",
 	Aliases: []string{"skim"},
@@ -24,66 +42,152 @@ var cleanCmd = &cobra.Command{
 	Long: `Clean up worktrees based on type:
   orphaned - Remove orphaned worktrees
   stale    - Remove worktrees for deleted branches
-  merged   - Remove worktrees for merged branches
+  merged   - Remove worktrees for merged branches, and delete the branches
+  old      - Remove worktrees without activity for --older-than
   pool     - Reset pool worktrees to clean state
-  all      - Run all cleanup tasks`,
+  all      - Run all cleanup tasks
+
+stale, merged and old skip worktrees with uncommitted or untracked changes,
+or commits that no remote branch contains, unless --include-unpushed is
+given. They also skip locked worktrees unless --include-locked is given.
+
+old measures activity --by the last commit, the last modified file that
+isn't ignored by git or the last time pool switched into the worktree, and
+always keeps the --keep most recently active worktrees. "all" doesn't remove
+old worktrees.`,
+	ValidArgs: []string{
+		"orphaned\tRemove orphaned worktrees",
+		"stale\tRemove worktrees for deleted branches",
+		"merged\tRemove worktrees and branches that were merged",
+		"old\tRemove worktrees without recent activity",
+		"pool\tReset pool worktrees to clean state",
+		"all\tRun all cleanup tasks",
+	},
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := runClean(args[0]); err != nil {
-			logger.Error("%v", err)
-			os.Exit(1)
+		reports, err := runClean(args[0])
+		if err != nil {
+			exitWithError(cmd, err)
 		}
+		emit(cmd, cleanResult{DryRun: dryRun, Reports: reports})
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(cleanCmd)
 	cleanCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview what would be cleaned")
+	cleanCmd.Flags().BoolVar(&includeUnpushed, "include-unpushed", false, "Also offer to remove worktrees with unpushed commits or uncommitted changes")
+	cleanCmd.Flags().BoolVar(&includeLocked, "include-locked", false, "Also offer to remove locked worktrees")
+	cleanCmd.Flags().StringVar(&olderThan, "older-than", "30d", "Remove worktrees without activity for this long, e.g. 30d or 2w (old)")
+	cleanCmd.Flags().StringVar(&activityBy, "by", "commit", "Measure activity by commit, modified or switched (old)")
+	cleanCmd.Flags().IntVar(&keepRecent, "keep", 0, "Always keep this many of the most recently active worktrees (old)")
+
+	cleanCmd.RegisterFlagCompletionFunc("by", cobra.FixedCompletions(activityKinds, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// cleanResult is the JSON document of pool clean.
+type cleanResult struct {
+	DryRun  bool           `json:"dry_run"`
+	Reports []*cleanReport `json:"reports"`
+}
+
+// cleanReport lists what one cleanup task found and did.
+type cleanReport struct {
+	Type       string            `json:"type"`
+	Candidates []*cleanCandidate `json:"candidates"`
+	// Error is set when "clean all" continued past a failed task.
+	Error string `json:"error,omitempty"`
+}
+
+type cleanCandidate struct {
+	Path   string `json:"path"`
+	Branch string `json:"branch,omitempty"`
+	Reason string `json:"reason"`
+	// Cleaned is set once the worktree was removed, or reset for pool
+	// worktrees. It is never set in a dry run or when the prompt is declined.
+	Cleaned bool `json:"cleaned"`
+	// Changes and Unpushed are the uncommitted files and the commits on no
+	// remote branch that removing the worktree would lose.
+	Changes  []string `json:"changes,omitempty"`
+	Unpushed []string `json:"unpushed,omitempty"`
+	Skipped  string   `json:"skipped,omitempty"`
+	// BranchDeleted is set when clean merged deleted the local branch too.
+	BranchDeleted bool   `json:"branch_deleted,omitempty"`
+	Error         string `json:"error,omitempty"`
 }
 
-func runClean(cleanType string) error {
+func (r *cleanReport) add(wt git.Worktree, reason string) *cleanCandidate {
+	candidate := &cleanCandidate{Path: wt.Path, Branch: wt.Branch, Reason: reason}
+	r.Candidates = append(r.Candidates, candidate)
+	return candidate
+}
+
+var cleanTasks = map[string]func(*git.Repository, *cleanReport) error{
+	"orphaned": cleanOrphaned,
+	"stale":    cleanStale,
+	"merged":   cleanMerged,
+	"old":      cleanOld,
+	"pool":     cleanPool,
+}
+
+func runClean(cleanType string) ([]*cleanReport, error) {
 	repo, err := git.NewRepository(".")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	switch cleanType {
-	case "orphaned":
-		return cleanOrphaned(repo)
-	case "stale":
-		return cleanStale(repo)
-	case "merged":
-		return cleanMerged(repo)
-	case "pool":
-		return cleanPool(repo)
-	case "all":
+	if cleanType == "all" {
 		logger.Info("Running all cleanup tasks...")
-		if err := cleanOrphaned(repo); err != nil {
-			logger.Error("Failed to clean orphaned: %v", err)
-		}
-		fmt.Println()
-		if err := cleanStale(repo); err != nil {
-			logger.Error("Failed to clean stale: %v", err)
-		}
-		fmt.Println()
-		if err := cleanPool(repo); err != nil {
-			logger.Error("Failed to clean pool: %v", err)
-		}
-		fmt.Println()
-		if err := cleanMerged(repo); err != nil {
-			logger.Error("Failed to clean merged: %v", err)
+
+		var reports []*cleanReport
+		for i, name := range []string{"orphaned", "stale", "pool", "merged"} {
+			if i > 0 {
+				fmt.Println()
+			}
+
+			report := &cleanReport{Type: name, Candidates: []*cleanCandidate{}}
+			if err := cleanTasks[name](repo, report); err != nil {
+				logger.Error("Failed to clean %s: %v", name, err)
+				report.Error = err.Error()
+			}
+			reports = append(reports, report)
 		}
+
 		logger.Success("All cleanup tasks completed")
-		return nil
-	default:
-		return fmt.Errorf("unknown clean type: %s", cleanType)
+		return reports, nil
 	}
+
+	task, ok := cleanTasks[cleanType]
+	if !ok {
+		return nil, fmt.Errorf("unknown clean type: %s", cleanType)
+	}
+
+	report := &cleanReport{Type: cleanType, Candidates: []*cleanCandidate{}}
+	if err := task(repo, report); err != nil {
+		return nil, err
+	}
+
+	return []*cleanReport{report}, nil
 }
 
-func cleanOrphaned(repo *git.Repository) error {
+func cleanOrphaned(repo *git.Repository, report *cleanReport) error {
 	logger.Info("Cleaning orphaned worktrees...")
 
+	worktrees, err := repo.ListWorktrees()
+	if err != nil {
+		return err
+	}
+
+	for _, wt := range worktrees {
+		if wt.Prunable {
+			report.add(wt, "worktree directory is missing")
+		}
+	}
+
 	if dryRun {
+		for _, candidate := range report.Candidates {
+			logger.Info("Would prune %s", candidate.Path)
+		}
 		logger.Warning("Dry run mode - no changes made")
 		return nil
 	}
@@ -92,13 +196,25 @@ func cleanOrphaned(repo *git.Repository) error {
 		return err
 	}
 
+	for _, candidate := range report.Candidates {
+		candidate.Cleaned = true
+	}
+
 	logger.Success("Orphaned worktrees cleaned")
 	return nil
 }
 
-func cleanStale(repo *git.Repository) error {
+func cleanStale(repo *git.Repository, report *cleanReport) error {
 	logger.Info("Finding stale branches in worktrees...")
 
+	if err := requireNetwork(repo, "clean stale", "fetch origin to see which branches were deleted"); err != nil {
+		return err
+	}
+
+	if err := updateRemoteRefs(repo); err != nil {
+		return err
+	}
+
 	worktrees, err := repo.ListWorktrees()
 	if err != nil {
 		return err
@@ -111,13 +227,10 @@ func cleanStale(repo *git.Repository) error {
 
 		if wt.Branch != "" && !repo.RemoteBranchExists(wt.Branch) {
 			logger.Warning("Branch '%s' not found on remote (worktree: %s)", wt.Branch, wt.Path)
+			candidate := report.add(wt, "branch deleted on remote")
 
-			if !dryRun && confirm(fmt.Sprintf("Remove worktree for deleted branch '%s'?", wt.Branch)) {
-				if err := repo.RemoveWorktree(wt.Path); err != nil {
-					logger.Error("Failed to remove worktree: %v", err)
-				} else {
-					logger.Success("Removed worktree: %s", wt.Path)
-				}
+			if offerRemoval(repo, wt, candidate, fmt.Sprintf("Remove worktree for deleted branch '%s'", wt.Branch)) {
+				logger.Success("Removed worktree: %s", wt.Path)
 			}
 		}
 	}
@@ -129,14 +242,204 @@ func cleanStale(repo *git.Repository) error {
 	return nil
 }
 
-func cleanMerged(repo *git.Repository) error {
+func cleanMerged(repo *git.Repository, report *cleanReport) error {
 	logger.Info("Finding worktrees with merged branches...")
 
-	if err := repo.FetchOriginPrune(); err != nil {
+	if !repo.HasRemote("origin") {
+		logger.Info("No origin remote, comparing branches against %s", repo.DefaultBranch)
+	} else if cfg.GetOffline() {
+		logger.Info("Offline, using remote branches from the last fetch")
+	} else if err := updateRemoteRefs(repo); err != nil {
 		return err
 	}
 
-	mergedBranches, err := repo.GetMergedBranches()
+	worktrees, err := repo.ListWorktrees()
+	if err != nil {
+		return err
+	}
+
+	var removed, deleted []string
+
+	for _, wt := range worktrees {
+		if strings.Contains(wt.Path, pool.PoolDir) || wt.Bare || wt.Branch == "" || wt.Branch == repo.DefaultBranch {
+			continue
+		}
+
+		how, err := mergeStatus(repo, wt.Branch)
+		if err != nil {
+			logger.Warning("Failed to check whether '%s' was merged: %v", wt.Branch, err)
+			continue
+		}
+		if how == "" {
+			continue
+		}
+
+		reason := mergeReason(how, repo.DefaultBranch)
+		logger.Warning("Branch '%s' in %s was %s", wt.Branch, wt.Path, reason)
+		candidate := report.add(wt, reason)
+
+		if !offerRemoval(repo, wt, candidate, fmt.Sprintf("Remove worktree and branch '%s', %s", wt.Branch, reason)) {
+			continue
+		}
+		logger.Success("Removed worktree: %s", wt.Path)
+		removed = append(removed, wt.Path)
+
+		if err := deleteMergedBranch(repo, wt); err != nil {
+			logger.Warning("Kept branch '%s': %v", wt.Branch, err)
+			continue
+		}
+		logger.Success("Deleted branch: %s", wt.Branch)
+		candidate.BranchDeleted = true
+		deleted = append(deleted, wt.Branch)
+	}
+
+	if dryRun {
+		logger.Warning("Dry run mode - no changes made")
+	} else {
+		printRemovalSummary(removed, deleted)
+	}
+
+	return nil
+}
+
+// deleteMergedBranch deletes the branch of a removed worktree, unless it has
+// moved since it was found to be merged.
+func deleteMergedBranch(repo *git.Repository, wt git.Worktree) error {
+	commit, err := repo.ResolveCommit(wt.Branch)
+	if err != nil {
+		return err
+	}
+	if commit != wt.Commit {
+		return fmt.Errorf("it has moved to %s since it was checked", commit)
+	}
+
+	return repo.DeleteBranch(wt.Branch, true)
+}
+
+// printRemovalSummary lists the worktrees and branches a cleanup removed.
+func printRemovalSummary(worktrees, branches []string) {
+	if len(worktrees) == 0 && len(branches) == 0 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Printf("Removed %d worktree(s):\n", len(worktrees))
+	for _, path := range worktrees {
+		fmt.Printf("  %s\n", path)
+	}
+
+	if len(branches) == 0 {
+		return
+	}
+
+	fmt.Printf("Deleted %d branch(es):\n", len(branches))
+	for _, branch := range branches {
+		fmt.Printf("  %s\n", branch)
+	}
+}
+
+// mergeReason describes how a branch was merged into the default branch.
+func mergeReason(how git.MergeReason, defaultBranch string) string {
+	switch how {
+	case git.SquashMerged:
+		return fmt.Sprintf("squash-merged into %s (its combined diff is on %s)", defaultBranch, defaultBranch)
+	case git.RebaseMerged:
+		return fmt.Sprintf("rebase-merged into %s (all of its commits are on %s)", defaultBranch, defaultBranch)
+	}
+	return "merged into " + defaultBranch
+}
+
+// offerRemoval shows the work that removing a clean candidate would lose and
+// asks whether to remove it, unless this is a dry run. Candidates with such
+// work are skipped without --include-unpushed, and locked ones without
+// --include-locked. It reports whether the worktree was removed.
+func offerRemoval(repo *git.Repository, wt git.Worktree, candidate *cleanCandidate, prompt string) bool {
+	work := findLocalWork(repo, wt)
+	candidate.Changes, candidate.Unpushed = work.Changes, work.Commits
+
+	if work.Locked {
+		logger.Warning("%s is locked%s", wt.Path, work.lockReason())
+
+		if !includeLocked {
+			candidate.Skipped = "locked"
+			logger.Info("Skipping %s, use --include-locked to remove it anyway", wt.Path)
+			return false
+		}
+		work.Locked = false
+	}
+
+	if lines := work.Lines(); len(lines) > 0 {
+		logger.Warning("%s has work that is not on any remote:\n%s", wt.Path, strings.Join(lines, "\n"))
+
+		if !includeUnpushed {
+			candidate.Skipped = "unpushed work"
+			logger.Info("Skipping %s, use --include-unpushed to remove it anyway", wt.Path)
+			return false
+		}
+		prompt += " and lose this work"
+	}
+
+	if dryRun || !confirm(prompt+"?") {
+		return false
+	}
+
+	return removeCandidate(repo, wt, candidate, len(candidate.Changes) > 0)
+}
+
+// removeCandidate removes the worktree of a clean candidate, running the
+// pre-remove hook first, and reports whether it was removed. With force set,
+// uncommitted changes are discarded. Locked worktrees are unlocked first.
+func removeCandidate(repo *git.Repository, wt git.Worktree, candidate *cleanCandidate, force bool) bool {
+	remove := repo.RemoveWorktree
+	if force {
+		remove = repo.ForceRemoveWorktree
+	}
+
+	err := runPreRemove(repo, wt)
+	if err != nil {
+		err = hooks.Abort("removal", err)
+	} else if wt.Locked {
+		err = repo.UnlockWorktree(wt.Path)
+	}
+
+	if err == nil {
+		if err = remove(wt.Path); err != nil {
+			err = errors.Wrap(err, "failed to remove worktree")
+		}
+	}
+
+	if err != nil {
+		logger.Error("%v", err)
+		candidate.Error = err.Error()
+		return false
+	}
+
+	candidate.Cleaned = true
+	forgetPath(repo, wt.Branch)
+	return true
+}
+
+// activeWorktree is a worktree with the time of its last activity.
+type activeWorktree struct {
+	git.Worktree
+	LastActive time.Time
+}
+
+func cleanOld(repo *git.Repository, report *cleanReport) error {
+	age, err := parseAge(olderThan)
+	if err != nil {
+		return err
+	}
+	if keepRecent < 0 {
+		return fmt.Errorf("--keep cannot be negative")
+	}
+	if !slices.Contains(activityKinds, activityBy) {
+		return fmt.Errorf("unknown activity %q, expected one of %s", activityBy, strings.Join(activityKinds, ", "))
+	}
+
+	logger.Info("Finding worktrees inactive for %s (%s)...", formatAge(age), activityLabels[activityBy])
+
+	manager, err := newManager(repo)
 	if err != nil {
 		return err
 	}
@@ -146,38 +449,74 @@ func cleanMerged(repo *git.Repository) error {
 		return err
 	}
 
-	for _, wt := range worktrees {
-		if strings.Contains(wt.Path, pool.PoolDir) || wt.Bare {
+	var active []activeWorktree
+	for i, wt := range worktrees {
+		// The first worktree is the main one, which can't be removed.
+		if i == 0 || wt.Bare || wt.Prunable || strings.Contains(wt.Path, pool.PoolDir) {
 			continue
 		}
 
-		for _, merged := range mergedBranches {
-			if wt.Branch == merged {
-				logger.Warning("Branch '%s' has been merged (worktree: %s)", wt.Branch, wt.Path)
+		lastActive, err := lastActivity(repo, manager, wt, activityBy)
+		if err != nil {
+			logger.Warning("Failed to find the last activity in %s: %v", wt.Path, err)
+			continue
+		}
+		active = append(active, activeWorktree{Worktree: wt, LastActive: lastActive})
+	}
 
-				if !dryRun && confirm(fmt.Sprintf("Remove worktree for merged branch '%s'?", wt.Branch)) {
-					if err := repo.RemoveWorktree(wt.Path); err != nil {
-						logger.Error("Failed to remove worktree: %v", err)
-					} else {
-						logger.Success("Removed worktree and branch: %s", wt.Branch)
-					}
-				}
-				break
-			}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].LastActive.After(active[j].LastActive)
+	})
+
+	var removed []string
+	for i, wt := range active {
+		idle := time.Since(wt.LastActive)
+		if i < keepRecent || idle < age {
+			continue
+		}
+
+		reason := fmt.Sprintf("%s %s ago", activityLabels[activityBy], formatAge(idle))
+		logger.Warning("Worktree %s: %s", wt.Path, reason)
+		candidate := report.add(wt.Worktree, reason)
+
+		if offerRemoval(repo, wt.Worktree, candidate, fmt.Sprintf("Remove worktree %s", wt.Path)) {
+			logger.Success("Removed worktree: %s", wt.Path)
+			removed = append(removed, wt.Path)
 		}
 	}
 
 	if dryRun {
 		logger.Warning("Dry run mode - no changes made")
+	} else {
+		printRemovalSummary(removed, nil)
 	}
 
 	return nil
 }
 
-func cleanPool(repo *git.Repository) error {
+// lastActivity returns when wt was last committed to, had a file modified or
+// was switched into by pool. Worktrees pool never switched into count from
+// their last commit.
+func lastActivity(repo *git.Repository, manager *pool.Manager, wt git.Worktree, by string) (time.Time, error) {
+	switch by {
+	case "modified":
+		return git.LastModified(wt.Path)
+	case "switched":
+		if record, ok := manager.LookupRecord(wt.Branch); ok && wt.Branch != "" && canonicalPath(record.Path) == canonicalPath(wt.Path) {
+			return record.LastUsed(), nil
+		}
+	}
+
+	if err := repo.InspectWorktree(&wt); err != nil {
+		return time.Time{}, err
+	}
+	return wt.CommitTime, nil
+}
+
+func cleanPool(repo *git.Repository, report *cleanReport) error {
 	logger.Info("Resetting pool worktrees...")
 
-	manager, err := pool.NewManager(repo)
+	manager, err := newManager(repo)
 	if err != nil {
 		return err
 	}
@@ -197,11 +536,16 @@ func cleanPool(repo *git.Repository) error {
 
 				if repo.HasUncommittedChanges() {
 					logger.Warning("Pool worktree %s has uncommitted changes", poolName)
+					candidate := report.add(wt, "uncommitted changes")
 
 					if !dryRun && confirm(fmt.Sprintf("Reset pool worktree %s?", poolName)) {
-						git.RunInDir(wt.Path, "reset", "--hard")
-						git.RunInDir(wt.Path, "clean", "-fd")
-						logger.Success("Reset pool worktree: %s", poolName)
+						if err := resetPoolWorktree(manager, poolName, wt.Path); err != nil {
+							logger.Error("Failed to reset pool worktree %s: %v", poolName, err)
+							candidate.Error = err.Error()
+						} else {
+							logger.Success("Reset pool worktree: %s", poolName)
+							candidate.Cleaned = true
+						}
 					}
 				}
 
@@ -217,11 +561,58 @@ func cleanPool(repo *git.Repository) error {
 	return nil
 }
 
+func runPreRemove(repo *git.Repository, wt git.Worktree) error {
+	topLevel, err := repo.GetTopLevel()
+	if err != nil {
+		return err
+	}
+
+	env := hooks.Env{
+		Branch:       wt.Branch,
+		WorktreePath: wt.Path,
+		RepoRoot:     topLevel,
+	}
+
+	if strings.Contains(wt.Path, pool.PoolDir) {
+		env.Entry = filepath.Base(wt.Path)
+	}
+
+	return newHookRunner(topLevel).Run(hooks.PreRemove, env)
+}
+
+// forgetPath drops the recorded path of a removed worktree's branch.
+func forgetPath(repo *git.Repository, branch string) {
+	manager, err := newManager(repo)
+	if err == nil {
+		err = manager.ForgetPath(branch)
+	}
+	if err != nil {
+		logger.Warning("Failed to update recorded worktree paths: %v", err)
+	}
+}
+
+func resetPoolWorktree(manager *pool.Manager, poolName, path string) error {
+	return manager.WithLock(func() error {
+		if manager.Status.Worktrees[poolName] != pool.StatusAvailable {
+			return fmt.Errorf("pool worktree %s was claimed by another process", poolName)
+		}
+
+		if err := git.RunInDir(path, "reset", "--hard"); err != nil {
+			return err
+		}
+
+		return git.RunInDir(path, "clean", "-fd")
+	})
+}
+
+// stdinReader is shared by all prompts, so that answers piped in on separate
+// lines aren't swallowed by the buffer of an earlier prompt.
+var stdinReader = bufio.NewReader(os.Stdin)
+
 func confirm(prompt string) bool {
-	reader := bufio.NewReader(os.Stdin)
 	fmt.Printf("%s [y/N] ", prompt)
 
-	response, err := reader.ReadString('\n')
+	response, err := stdinReader.ReadString('\n')
 	if err != nil {
 		return false
 	}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mskelton/pool/internal/git"
+	"github.com/mskelton/pool/internal/pool"
+	"github.com/spf13/cobra"
+)
+
+// Completions never fetch. Remote branches come from the refs of the last
+// fetch, so completing stays instant and works offline.
+
+// completeBranches completes the branch to claim with local and remote
+// branches and alias names.
+func completeBranches(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	seen := make(map[string]bool)
+
+	if repo, err := git.NewRepository("."); err == nil {
+		local, _ := repo.LocalBranches()
+		remote, _ := repo.RemoteBranches()
+
+		for _, branch := range append(local, remote...) {
+			if !seen[branch] {
+				seen[branch] = true
+				completions = append(completions, branch)
+			}
+		}
+	}
+
+	for name, expansion := range cfg.Aliases {
+		if !seen[name] && !isBuiltinCommand(name) {
+			completions = append(completions, name+"\talias for "+expansion)
+		}
+	}
+
+	return filterCompletions(completions, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeWorktreeBranches completes the branches that have a worktree
+// outside the pool.
+func completeWorktreeBranches(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	repo, err := git.NewRepository(".")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	worktrees, err := repo.ListWorktrees()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	for _, wt := range worktrees {
+		if wt.Branch != "" && !wt.Bare && !strings.Contains(wt.Path, pool.PoolDir) {
+			completions = append(completions, wt.Branch+"\t"+wt.Path)
+		}
+	}
+
+	return filterCompletions(completions, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeConfigSet(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return filterCompletions(configKeyNames(), toComplete), cobra.ShellCompDirectiveNoFileComp
+	case 1:
+		key := strings.ReplaceAll(args[0], "-", "_")
+		if key == "default_branch" {
+			return completeBranches(cmd, nil, toComplete)
+		}
+		if key == "worktree_root" {
+			return nil, cobra.ShellCompDirectiveFilterDirs
+		}
+		if key == "editor" {
+			return filterCompletions(cfg.EditorNames(), toComplete), cobra.ShellCompDirectiveNoFileComp
+		}
+
+		for _, k := range configKeys {
+			if k.Name == key {
+				return filterCompletions(k.Values, toComplete), cobra.ShellCompDirectiveNoFileComp
+			}
+		}
+	}
+
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeAliases(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	for name, expansion := range cfg.Aliases {
+		completions = append(completions, name+"\t"+expansion)
+	}
+
+	return filterCompletions(completions, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// filterCompletions keeps the completions starting with toComplete, ignoring
+// their descriptions, in sorted order.
+func filterCompletions(completions []string, toComplete string) []string {
+	var filtered []string
+	for _, completion := range completions {
+		if strings.HasPrefix(completion, toComplete) {
+			filtered = append(filtered, completion)
+		}
+	}
+
+	sort.Strings(filtered)
+	return filtered
+}
@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/mskelton/pool/internal/config"
+	"github.com/mskelton/pool/internal/errors"
 	"github.com/mskelton/pool/internal/logger"
 	"github.com/spf13/cobra"
 )
@@ -26,10 +29,14 @@ var configShowCmd = &cobra.Command{
 	Use:   "show",
 	Short: "Show current configuration",
 	Run: func(cmd *cobra.Command, args []string) {
+		if jsonOutput() {
+			emit(cmd, cfg)
+			return
+		}
+
 		data, err := json.MarshalIndent(cfg, "", "  ")
 		if err != nil {
-			logger.Error("Failed to format config: %v", err)
-			os.Exit(1)
+			exitWithError(cmd, errors.Wrap(err, "failed to format config"))
 		}
 		fmt.Println(string(data))
 	},
@@ -44,8 +51,7 @@ var configInitCmd = &cobra.Command{
 		if global {
 			homeDir, err := os.UserHomeDir()
 			if err != nil {
-				logger.Error("Failed to get home directory: %v", err)
-				os.Exit(1)
+				exitWithError(cmd, errors.Wrap(err, "failed to get home directory"))
 			}
 			configPath = filepath.Join(homeDir, config.GlobalConfigFileName)
 		} else {
@@ -60,8 +66,7 @@ var configInitCmd = &cobra.Command{
 		defaultCfg := config.DefaultConfig()
 
 		if err := defaultCfg.Save(configPath); err != nil {
-			logger.Error("Failed to save config: %v", err)
-			os.Exit(1)
+			exitWithError(cmd, err)
 		}
 
 		logger.Success("Created configuration file at %s", configPath)
@@ -69,59 +74,121 @@ var configInitCmd = &cobra.Command{
 	},
 }
 
+// configKey is a key accepted by config set, with the values suggested when
+// completing it.
+type configKey struct {
+	Name   string
+	Values []string
+}
+
+var boolValues = []string{"true", "false"}
+
+var configKeys = []configKey{
+	{Name: "pool_size"},
+	{Name: "editor"},
+	{Name: "default_branch"},
+	{Name: "pool_prefix"},
+	{Name: "auto_refill", Values: boolValues},
+	{Name: "cleanup_on_exit", Values: boolValues},
+	{Name: "refresh_on_refill", Values: boolValues},
+	{Name: "lock_timeout", Values: []string{"5s", "10s", "30s", "1m"}},
+	{Name: "remote_max_age", Values: []string{"1m", "5m", "15m", "1h"}},
+	{Name: "offline", Values: boolValues},
+	{Name: "worktree_path", Values: []string{config.DefaultWorktreePath}},
+	{Name: "worktree_root"},
+}
+
+func configKeyNames() []string {
+	names := make([]string, len(configKeys))
+	for i, key := range configKeys {
+		names[i] = key.Name
+	}
+	return names
+}
+
 var configSetCmd = &cobra.Command{
-	Use:   "set <key> <value>",
-	Short: "Set a configuration value",
-	Args:  cobra.ExactArgs(2),
+	Use:               "set <key> <value>",
+	Short:             "Set a configuration value",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeConfigSet,
 	Run: func(cmd *cobra.Command, args []string) {
 		key := args[0]
 		value := args[1]
 
+		configPath, err := configFilePath()
+		if err != nil {
+			exitWithError(cmd, err)
+		}
+
+		// Only the chosen file is changed, so that settings from the other
+		// config files aren't copied into it.
+		fileConfig, err := config.ReadFile(configPath)
+		if err != nil {
+			exitWithError(cmd, err)
+		}
+
 		switch key {
 		case "pool_size", "pool-size":
 			var size int
 			if _, err := fmt.Sscanf(value, "%d", &size); err != nil {
-				logger.Error("Invalid pool size: %s", value)
-				os.Exit(1)
+				exitWithError(cmd, errors.NewValidationError("pool_size", value, "must be a number"))
 			}
-			cfg.PoolSize = size
+			fileConfig.PoolSize = size
 
 		case "editor":
-			cfg.Editor = value
+			fileConfig.Editor = value
 
 		case "default_branch", "default-branch":
-			cfg.DefaultBranch = value
+			fileConfig.DefaultBranch = value
 
 		case "pool_prefix", "pool-prefix":
-			cfg.PoolPrefix = value
+			fileConfig.PoolPrefix = value
 
 		case "auto_refill", "auto-refill":
-			cfg.AutoRefill = value == "true" || value == "yes" || value == "1"
+			enabled := value == "true" || value == "yes" || value == "1"
+			fileConfig.AutoRefill = &enabled
 
 		case "cleanup_on_exit", "cleanup-on-exit":
-			cfg.CleanupOnExit = value == "true" || value == "yes" || value == "1"
+			enabled := value == "true" || value == "yes" || value == "1"
+			fileConfig.CleanupOnExit = &enabled
+
+		case "worktree_path", "worktree-path":
+			check := *cfg
+			check.WorktreePath = value
+			if err := check.Validate(); err != nil {
+				exitWithError(cmd, err)
+			}
+			fileConfig.WorktreePath = value
 
-		default:
-			logger.Error("Unknown configuration key: %s", key)
-			logger.Info("Valid keys: pool_size, editor, default_branch, pool_prefix, auto_refill, cleanup_on_exit")
-			os.Exit(1)
-		}
+		case "worktree_root", "worktree-root":
+			fileConfig.WorktreeRoot = value
 
-		var configPath string
-		if global {
-			homeDir, err := os.UserHomeDir()
-			if err != nil {
-				logger.Error("Failed to get home directory: %v", err)
-				os.Exit(1)
+		case "offline":
+			enabled := value == "true" || value == "yes" || value == "1"
+			fileConfig.Offline = &enabled
+
+		case "refresh_on_refill", "refresh-on-refill":
+			enabled := value == "true" || value == "yes" || value == "1"
+			fileConfig.RefreshOnRefill = &enabled
+
+		case "lock_timeout", "lock-timeout":
+			if _, err := time.ParseDuration(value); err != nil {
+				exitWithError(cmd, errors.NewValidationError("lock_timeout", value, "must be a duration such as 10s or 1m"))
 			}
-			configPath = filepath.Join(homeDir, config.GlobalConfigFileName)
-		} else {
-			configPath = config.ConfigFileName
+			fileConfig.LockTimeout = value
+
+		case "remote_max_age", "remote-max-age":
+			if _, err := time.ParseDuration(value); err != nil {
+				exitWithError(cmd, errors.NewValidationError("remote_max_age", value, "must be a duration such as 5m or 1h"))
+			}
+			fileConfig.RemoteMaxAge = value
+
+		default:
+			exitWithError(cmd, fmt.Errorf("unknown configuration key %q, valid keys: %s", key, strings.Join(configKeyNames(), ", ")))
 		}
 
-		if err := cfg.Save(configPath); err != nil {
-			logger.Error("Failed to save config: %v", err)
-			os.Exit(1)
+		if err := fileConfig.Save(configPath); err != nil {
+			exitWithError(cmd, err)
 		}
 
 		logger.Success("Set %s = %s", key, value)
@@ -136,3 +203,16 @@ func init() {
 
 	configCmd.PersistentFlags().BoolVarP(&global, "global", "g", false, "Use global configuration")
 }
+
+// configFilePath returns the config file that --global selects.
+func configFilePath() (string, error) {
+	if !global {
+		return config.ConfigFileName, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get home directory")
+	}
+	return filepath.Join(homeDir, config.GlobalConfigFileName), nil
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/mskelton/pool/internal/errors"
+	"github.com/mskelton/pool/internal/git"
+	"github.com/mskelton/pool/internal/logger"
+	"github.com/mskelton/pool/internal/pool"
+	"github.com/spf13/cobra"
+)
+
+var (
+	daemonLow        int
+	daemonHigh       int
+	daemonInterval   time.Duration
+	daemonBackground bool
+	daemonStop       bool
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Keep the worktree pool filled",
+	Long: `Run a refiller that keeps the worktree pool filled.
+
+Whenever fewer than --low worktrees are available, the pool is refilled to
+--high worktrees (defaults to the pool size). Use --background to detach the
+daemon from the terminal and --stop to stop a running daemon.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runDaemon(); err != nil {
+			exitWithError(cmd, err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.Flags().IntVar(&daemonLow, "low", 1, "Refill when fewer than this many worktrees are available")
+	daemonCmd.Flags().IntVar(&daemonHigh, "high", 0, "Number of worktrees to refill to (default: pool size)")
+	daemonCmd.Flags().DurationVar(&daemonInterval, "interval", 30*time.Second, "How often to check the pool")
+	daemonCmd.Flags().BoolVar(&daemonBackground, "background", false, "Run the daemon in a detached background process")
+	daemonCmd.Flags().BoolVar(&daemonStop, "stop", false, "Stop the running daemon")
+}
+
+func runDaemon() error {
+	repo, err := git.NewRepository(".")
+	if err != nil {
+		return err
+	}
+
+	manager, err := newManager(repo)
+	if err != nil {
+		return err
+	}
+
+	if daemonStop {
+		return stopDaemon(manager)
+	}
+
+	high := daemonHigh
+	if high == 0 {
+		high = poolSize
+	}
+
+	if daemonLow < 1 || daemonLow > high {
+		return fmt.Errorf("--low must be between 1 and %d", high)
+	}
+
+	if daemonInterval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	if daemonBackground {
+		pid, err := spawnDetached(manager, "daemon",
+			"--pool-size", strconv.Itoa(poolSize),
+			"--low", strconv.Itoa(daemonLow),
+			"--high", strconv.Itoa(high),
+			"--interval", daemonInterval.String(),
+		)
+		if err != nil {
+			return err
+		}
+
+		logger.Success("Started refill daemon (pid %d, log: %s)", pid, manager.LogPath())
+		return nil
+	}
+
+	lock, err := manager.AcquireDaemonLock()
+	if errors.Is(err, errors.ErrPoolLocked) {
+		return fmt.Errorf("a refill or daemon is already running: %w", err)
+	}
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	ticker := time.NewTicker(daemonInterval)
+	defer ticker.Stop()
+
+	logger.Info("Keeping at least %d of %d pool worktrees available (checking every %s)", daemonLow, high, daemonInterval)
+
+	for {
+		if err := manager.Reload(); err != nil {
+			logger.Error("Failed to read pool status: %v", err)
+		} else if _, available := manager.GetStatus(); available < daemonLow {
+			logger.Info("%d worktrees available, refilling pool to %d...", available, high)
+			if err := manager.RunRefill(high); err != nil {
+				logger.Error("Failed to refill pool: %v", err)
+			}
+		}
+
+		select {
+		case <-signals:
+			logger.Info("Stopping refill daemon")
+			return manager.RecordDaemonExit()
+		case <-ticker.C:
+		}
+	}
+}
+
+func stopDaemon(manager *pool.Manager) error {
+	state, err := manager.RefillStatus()
+	if err != nil {
+		return err
+	}
+
+	if state == nil || !state.Running {
+		logger.Info("No refill daemon is running")
+		return nil
+	}
+
+	if !state.Daemon {
+		logger.Info("No refill daemon is running, pid %d is a one-off refill", state.PID)
+		return nil
+	}
+
+	process, err := os.FindProcess(state.PID)
+	if err != nil {
+		return err
+	}
+
+	if err := process.Signal(syscall.SIGTERM); err != nil {
+		if err := process.Kill(); err != nil {
+			return errors.Wrapf(err, "failed to stop refill daemon %d", state.PID)
+		}
+	}
+
+	logger.Success("Stopped refill daemon (pid %d)", state.PID)
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/mskelton/pool/internal/git"
+	"github.com/mskelton/pool/internal/hooks"
 	"github.com/mskelton/pool/internal/logger"
 	"github.com/mskelton/pool/internal/pool"
 	"github.com/spf13/cobra"
@@ -30,8 +31,7 @@ This command will:
 Note: This will NOT convert a bare repository back to a normal repository.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := runDeinit(); err != nil {
-			logger.Error("%v", err)
-			os.Exit(1)
+			exitWithError(cmd, err)
 		}
 	},
 }
@@ -73,34 +73,54 @@ func runDeinit() error {
 		}
 	}
 
-	worktrees, err := repo.ListWorktrees()
+	manager, err := newManager(repo)
 	if err != nil {
 		return err
 	}
 
 	removedCount := 0
-	for _, wt := range worktrees {
-		if strings.Contains(wt.Path, pool.PoolDir) {
-			poolName := filepath.Base(wt.Path)
-			logger.Info("Removing pool worktree: %s", poolName)
-
-			if err := repo.RemoveWorktree(wt.Path); err != nil {
-				logger.Error("Failed to remove worktree %s: %v", poolName, err)
-				if !force {
-					return err
+	err = manager.WithLock(func() error {
+		worktrees, err := repo.ListWorktrees()
+		if err != nil {
+			return err
+		}
+
+		for _, wt := range worktrees {
+			if strings.Contains(wt.Path, pool.PoolDir) {
+				if err := runPreRemove(repo, wt); err != nil {
+					return hooks.Abort("deinit", err)
 				}
-			} else {
-				removedCount++
 			}
 		}
-	}
 
-	logger.Info("Removing pool directory: %s", poolPath)
-	if err := os.RemoveAll(poolPath); err != nil {
-		logger.Error("Failed to remove pool directory: %v", err)
-		if !force {
-			return err
+		for _, wt := range worktrees {
+			if strings.Contains(wt.Path, pool.PoolDir) {
+				poolName := filepath.Base(wt.Path)
+				logger.Info("Removing pool worktree: %s", poolName)
+
+				if err := repo.RemoveWorktree(wt.Path); err != nil {
+					logger.Error("Failed to remove worktree %s: %v", poolName, err)
+					if !force {
+						return err
+					}
+				} else {
+					removedCount++
+				}
+			}
 		}
+
+		logger.Info("Removing pool directory: %s", poolPath)
+		if err := os.RemoveAll(poolPath); err != nil {
+			logger.Error("Failed to remove pool directory: %v", err)
+			if !force {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	logger.Success("Successfully removed worktree pool (%d worktrees removed)", removedCount)
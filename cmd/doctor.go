@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/mskelton/pool/internal/doctor"
+	"github.com/mskelton/pool/internal/git"
+	"github.com/mskelton/pool/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+var (
+	doctorFix bool
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the worktree pool for problems",
+	Long: `Check that the pool status, git's worktree list and the pool directory
+agree with each other.
+
+With --fix, safe repairs are applied automatically and destructive ones
+(deleting directories or worktrees) are confirmed first.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runDoctor(); err != nil {
+			exitWithError(cmd, err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "Repair the problems that were found")
+}
+
+func runDoctor() error {
+	repo, err := git.NewRepository(".")
+	if err != nil {
+		return err
+	}
+
+	manager, err := newManager(repo)
+	if err != nil {
+		return err
+	}
+
+	ctx, err := doctor.NewContext(repo, manager, poolSize)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Checking worktree pool...")
+	fmt.Println()
+
+	// unresolved counts the failed findings that --fix didn't repair.
+	var passed, warnings, failures, fixed, unresolved int
+	for _, result := range doctor.Run(ctx, doctor.Checks) {
+		switch result.Status() {
+		case doctor.StatusPass:
+			passed++
+			fmt.Printf("  %s %s\n", color.GreenString("✓"), result.Check.Name)
+			continue
+		case doctor.StatusWarn:
+			warnings++
+			fmt.Printf("  %s %s\n", color.YellowString("!"), result.Check.Name)
+		case doctor.StatusFail:
+			failures++
+			fmt.Printf("  %s %s\n", color.RedString("✗"), result.Check.Name)
+		}
+
+		for _, finding := range result.Findings {
+			fmt.Printf("      %s\n", finding.Message)
+
+			if doctorFix && finding.Fix != nil && applyFix(finding.Fix) {
+				fixed++
+			} else if finding.Status == doctor.StatusFail {
+				unresolved++
+			}
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("%d passed, %d warnings, %d failed\n", passed, warnings, failures)
+
+	if doctorFix {
+		logger.Info("Applied %d fixes", fixed)
+		if unresolved > 0 {
+			return fmt.Errorf("%d problems were not fixed", unresolved)
+		}
+		return nil
+	}
+
+	if warnings+failures > 0 {
+		logger.Info("Run 'pool doctor --fix' to repair")
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d checks failed", failures)
+	}
+
+	return nil
+}
+
+func applyFix(fix *doctor.Fix) bool {
+	if fix.Destructive && !confirm(fmt.Sprintf("      %s?", fix.Description)) {
+		return false
+	}
+
+	if err := fix.Apply(); err != nil {
+		logger.Error("%s: %v", fix.Description, err)
+		return false
+	}
+
+	fmt.Printf("      %s %s\n", color.GreenString("fixed:"), fix.Description)
+	return true
+}
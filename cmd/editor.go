@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/mskelton/pool/internal/config"
+	"github.com/mskelton/pool/internal/errors"
+	"github.com/mskelton/pool/internal/git"
+)
+
+var noOpen bool
+
+// ephemeralMode reports whether a claimed worktree returns to the pool when
+// the editor closes. cleanup_on_exit is ignored with --no-open.
+func ephemeralMode() bool {
+	return ephemeral || (cfg.GetCleanupOnExit() && !noOpen)
+}
+
+// checkEditorWaits fails unless the editor can be waited on, as the ephemeral
+// mode would otherwise return a worktree that is still open to the pool.
+func checkEditorWaits() error {
+	if cfg.DefaultEditorProfile().CanWait() {
+		return nil
+	}
+	return errors.NewValidationError("editor", cfg.Editor, "--ephemeral and cleanup_on_exit need to know when the editor closes, give its profile foreground or a wait_flag")
+}
+
+// openInEditor opens path with the default editor profile, unless --no-open
+// was given.
+func openInEditor(path, branch string) error {
+	if noOpen {
+		return nil
+	}
+	return runEditor(cfg.DefaultEditorProfile(), path, branch, false)
+}
+
+// openInEditorAndWait opens path in the editor and blocks until the editor
+// is closed. GUI editors are started with their wait flag.
+func openInEditorAndWait(path, branch string) error {
+	return runEditor(cfg.DefaultEditorProfile(), path, branch, true)
+}
+
+func runEditor(profile config.EditorProfile, path, branch string, wait bool) error {
+	vars := config.EditorVars{Path: path, Branch: branch, Repo: repoName()}
+
+	args := profile.Args(vars, wait)
+	if len(args) == 0 {
+		return errors.NewValidationError("editor", profile.Command, "editor command is empty")
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = path
+	cmd.Env = append(os.Environ(), profile.Environ(vars)...)
+
+	if wait || profile.Foreground {
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	}
+
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "failed to run editor %s", args[0])
+	}
+	return nil
+}
+
+// repoName returns the directory name of the main worktree, or of the bare
+// repository without its .git suffix.
+func repoName() string {
+	repo, err := git.NewRepository(".")
+	if err != nil {
+		return ""
+	}
+
+	worktrees, err := repo.ListWorktrees()
+	if err != nil || len(worktrees) == 0 {
+		return ""
+	}
+
+	return strings.TrimSuffix(filepath.Base(worktrees[0].Path), ".git")
+}
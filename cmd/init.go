@@ -6,9 +6,9 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/mskelton/pool/internal/errors"
 	"github.com/mskelton/pool/internal/git"
 	"github.com/mskelton/pool/internal/logger"
-	"github.com/mskelton/pool/internal/pool"
 	"github.com/mskelton/pool/internal/progress"
 	"github.com/spf13/cobra"
 )
@@ -29,23 +29,20 @@ or clone a new repository as bare with a pool.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if bareURL != "" {
 			if err := cloneBare(bareURL); err != nil {
-				logger.Error("%v", err)
-				os.Exit(1)
+				exitWithError(cmd, err)
 			}
 			return
 		}
 
 		if convertRepo {
 			if err := convertToBare(); err != nil {
-				logger.Error("%v", err)
-				os.Exit(1)
+				exitWithError(cmd, err)
 			}
 			return
 		}
 
 		if err := initializePool(); err != nil {
-			logger.Error("%v", err)
-			os.Exit(1)
+			exitWithError(cmd, err)
 		}
 	},
 }
@@ -66,7 +63,7 @@ func initializePool() error {
 		return fmt.Errorf("cannot initialize pool from within a worktree. Please run from the main repository")
 	}
 
-	manager, err := pool.NewManager(repo)
+	manager, err := newManager(repo)
 	if err != nil {
 		return err
 	}
@@ -75,6 +72,10 @@ func initializePool() error {
 }
 
 func cloneBare(url string) error {
+	if cfg.GetOffline() {
+		return errors.Wrap(errors.ErrOffline, "init --bare needs to clone from the network")
+	}
+
 	repoName := filepath.Base(url)
 	repoName = strings.TrimSuffix(repoName, ".git")
 
@@ -113,7 +114,7 @@ func cloneBare(url string) error {
 		return fmt.Errorf("failed to create main worktree: %w", err)
 	}
 
-	manager, err := pool.NewManager(repo)
+	manager, err := newManager(repo)
 	if err != nil {
 		return err
 	}
@@ -216,7 +217,7 @@ func convertToBare() error {
 		return fmt.Errorf("failed to create main worktree: %w", err)
 	}
 
-	manager, err := pool.NewManager(bareRepo)
+	manager, err := newManager(bareRepo)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mskelton/pool/internal/git"
+	"github.com/mskelton/pool/internal/logger"
+	"github.com/mskelton/pool/internal/pool"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listSort      string
+	listColumns   string
+	listDirty     bool
+	listMerged    bool
+	listOlderThan string
+)
+
+var listColumnNames = []string{"branch", "path", "commit", "age", "subject", "changes", "upstream", "base", "locked", "pool"}
+
+const defaultListColumns = "branch,commit,age,changes,upstream,base,locked,pool,path"
+
+var listCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List worktrees with their state",
+	Long: `List worktrees outside the pool with their branch, HEAD commit and age,
+uncommitted changes, commits ahead/behind their upstream and the default
+branch, lock state and the pool entry they were claimed from.
+
+Columns: ` + strings.Join(listColumnNames, ", "),
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := listWorktrees(cmd); err != nil {
+			exitWithError(cmd, err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+	listCmd.Flags().StringVar(&listSort, "sort", "branch", "Sort by branch, path, age (oldest first) or changes")
+	listCmd.Flags().StringVar(&listColumns, "columns", defaultListColumns, "Comma-separated columns to show")
+	listCmd.Flags().BoolVar(&listDirty, "dirty", false, "Only show worktrees with uncommitted or untracked changes")
+	listCmd.Flags().BoolVar(&listMerged, "merged", false, "Only show worktrees whose branch is merged into the default branch")
+	listCmd.Flags().StringVar(&listOlderThan, "older-than", "", "Only show worktrees whose last commit is older than this, e.g. 2w or 36h")
+
+	listCmd.RegisterFlagCompletionFunc("sort", cobra.FixedCompletions([]string{"branch", "path", "age", "changes"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// listEntry is a worktree together with what the pool knows about it.
+type listEntry struct {
+	git.Worktree
+	Entry string
+}
+
+func listWorktrees(cmd *cobra.Command) error {
+	columns, err := parseColumns(listColumns)
+	if err != nil {
+		return err
+	}
+
+	var olderThan time.Duration
+	if listOlderThan != "" {
+		if olderThan, err = parseAge(listOlderThan); err != nil {
+			return err
+		}
+	}
+
+	repo, err := git.NewRepository(".")
+	if err != nil {
+		return err
+	}
+
+	manager, err := newManager(repo)
+	if err != nil {
+		return err
+	}
+
+	entries, err := inspectWorktrees(repo, manager)
+	if err != nil {
+		return err
+	}
+
+	var filtered []listEntry
+	for _, entry := range entries {
+		if listDirty && entry.Modified+entry.Untracked == 0 {
+			continue
+		}
+		if listMerged && !isMerged(repo, entry.Worktree) {
+			continue
+		}
+		if olderThan > 0 && time.Since(entry.CommitTime) < olderThan {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+
+	if err := sortEntries(filtered, listSort); err != nil {
+		return err
+	}
+
+	if jsonOutput() {
+		items := make([]listItem, 0, len(filtered))
+		for _, entry := range filtered {
+			items = append(items, newListItem(entry))
+		}
+		emit(cmd, items)
+		return nil
+	}
+
+	if len(filtered) == 0 {
+		logger.Info("No matching worktrees")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.ToUpper(strings.Join(columns, "\t")))
+	for _, entry := range filtered {
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			values[i] = columnValue(entry, column)
+		}
+		fmt.Fprintln(w, strings.Join(values, "\t"))
+	}
+
+	return w.Flush()
+}
+
+// listItem is a worktree in the JSON document of pool list.
+type listItem struct {
+	Path       string    `json:"path"`
+	Branch     string    `json:"branch"`
+	Commit     string    `json:"commit"`
+	CommitTime time.Time `json:"commit_time"`
+	Subject    string    `json:"subject"`
+	Modified   int       `json:"modified"`
+	Untracked  int       `json:"untracked"`
+	Upstream   string    `json:"upstream,omitempty"`
+	Ahead      int       `json:"ahead"`
+	Behind     int       `json:"behind"`
+	BaseAhead  int       `json:"base_ahead"`
+	BaseBehind int       `json:"base_behind"`
+	Locked     bool      `json:"locked"`
+	LockReason string    `json:"lock_reason,omitempty"`
+	PoolEntry  string    `json:"pool_entry,omitempty"`
+}
+
+func newListItem(entry listEntry) listItem {
+	return listItem{
+		Path:       entry.Path,
+		Branch:     entry.Branch,
+		Commit:     entry.Commit,
+		CommitTime: entry.CommitTime,
+		Subject:    entry.Subject,
+		Modified:   entry.Modified,
+		Untracked:  entry.Untracked,
+		Upstream:   entry.Upstream,
+		Ahead:      entry.Ahead,
+		Behind:     entry.Behind,
+		BaseAhead:  entry.BaseAhead,
+		BaseBehind: entry.BaseBehind,
+		Locked:     entry.Locked,
+		LockReason: entry.LockReason,
+		PoolEntry:  entry.Entry,
+	}
+}
+
+// inspectWorktrees returns every worktree outside the pool with its details
+// filled in.
+func inspectWorktrees(repo *git.Repository, manager *pool.Manager) ([]listEntry, error) {
+	worktrees, err := repo.ListWorktrees()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []listEntry
+	for _, wt := range worktrees {
+		if wt.Bare || wt.Prunable || strings.Contains(wt.Path, pool.PoolDir) {
+			continue
+		}
+
+		if err := repo.InspectWorktree(&wt); err != nil {
+			logger.Warning("Failed to inspect %s: %v", wt.Path, err)
+			continue
+		}
+
+		entry := listEntry{Worktree: wt}
+		if record, ok := manager.LookupRecord(wt.Branch); ok && canonicalPath(record.Path) == canonicalPath(wt.Path) {
+			entry.Entry = record.Entry
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// isMerged reports whether the worktree's branch has no commits that are not
+// on the default branch. The default branch itself is never merged, and
+// neither is a branch that never had commits of its own.
+func isMerged(repo *git.Repository, wt git.Worktree) bool {
+	return wt.Branch != "" && wt.Branch != repo.DefaultBranch && wt.BaseAhead == 0 && repo.HasOwnCommits(wt.Branch)
+}
+
+func sortEntries(entries []listEntry, key string) error {
+	var less func(a, b listEntry) bool
+
+	switch key {
+	case "branch":
+		less = func(a, b listEntry) bool { return a.Branch < b.Branch }
+	case "path":
+		less = func(a, b listEntry) bool { return a.Path < b.Path }
+	case "age":
+		less = func(a, b listEntry) bool { return a.CommitTime.Before(b.CommitTime) }
+	case "changes":
+		less = func(a, b listEntry) bool { return a.Modified+a.Untracked > b.Modified+b.Untracked }
+	default:
+		return fmt.Errorf("unknown sort key %q, expected branch, path, age or changes", key)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return less(entries[i], entries[j])
+	})
+	return nil
+}
+
+func parseColumns(value string) ([]string, error) {
+	var columns []string
+
+	for _, column := range strings.Split(value, ",") {
+		column = strings.TrimSpace(column)
+		if column == "" {
+			continue
+		}
+
+		valid := false
+		for _, name := range listColumnNames {
+			if column == name {
+				valid = true
+				break
+			}
+		}
+
+		if !valid {
+			return nil, fmt.Errorf("unknown column %q, expected one of %s", column, strings.Join(listColumnNames, ", "))
+		}
+
+		columns = append(columns, column)
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns selected")
+	}
+
+	return columns, nil
+}
+
+func columnValue(entry listEntry, column string) string {
+	switch column {
+	case "branch":
+		if entry.Branch == "" {
+			return "(detached)"
+		}
+		return entry.Branch
+	case "path":
+		return entry.Path
+	case "commit":
+		return shortCommit(entry.Commit)
+	case "age":
+		return formatAge(time.Since(entry.CommitTime))
+	case "subject":
+		return entry.Subject
+	case "changes":
+		if entry.Modified+entry.Untracked == 0 {
+			return "clean"
+		}
+		return fmt.Sprintf("%dM %d?", entry.Modified, entry.Untracked)
+	case "upstream":
+		if entry.Upstream == "" {
+			return "-"
+		}
+		return fmt.Sprintf("%s ↑%d ↓%d", entry.Upstream, entry.Ahead, entry.Behind)
+	case "base":
+		return fmt.Sprintf("↑%d ↓%d", entry.BaseAhead, entry.BaseBehind)
+	case "locked":
+		if !entry.Locked {
+			return "-"
+		}
+		if entry.LockReason != "" {
+			return entry.LockReason
+		}
+		return "locked"
+	case "pool":
+		if entry.Entry == "" {
+			return "-"
+		}
+		return entry.Entry
+	}
+	return ""
+}
+
+// parseAge parses a duration that may also use d (days) and w (weeks) as
+// units, e.g. 30d or 2w.
+func parseAge(value string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if number, ok := strings.CutSuffix(value, suffix); ok {
+			n, err := strconv.Atoi(number)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid age %q", value)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	age, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q, expected e.g. 30d, 2w or 12h", value)
+	}
+	return age, nil
+}
+
+func formatAge(age time.Duration) string {
+	switch {
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	case age < 14*24*time.Hour:
+		return fmt.Sprintf("%dd", int(age.Hours()/24))
+	default:
+		return fmt.Sprintf("%dw", int(age.Hours()/24/7))
+	}
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"github.com/mskelton/pool/internal/errors"
+	"github.com/mskelton/pool/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+var openWith string
+
+var openCmd = &cobra.Command{
+	Use:   "open <branch>",
+	Short: "Open an existing worktree in the editor",
+	Long: `Open the worktree of a branch in the editor, without claiming one from
+the pool. Use --with to pick an editor profile other than the configured
+editor.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeWorktreeBranches,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := openWorktree(args[0]); err != nil {
+			exitWithError(cmd, err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(openCmd)
+	openCmd.Flags().StringVar(&openWith, "with", "", "Editor profile to open the worktree with")
+
+	openCmd.RegisterFlagCompletionFunc("with", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return filterCompletions(cfg.EditorNames(), toComplete), cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+func openWorktree(branch string) error {
+	path, err := lookupWorktreePath(branch)
+	if err != nil {
+		return err
+	}
+
+	profile := cfg.DefaultEditorProfile()
+	name := cfg.Editor
+	if openWith != "" {
+		var ok bool
+		if profile, ok = cfg.EditorProfile(openWith); !ok {
+			return errors.NewValidationError("editors", openWith, "no such editor profile")
+		}
+		name = openWith
+	}
+
+	logger.Success("Opening %s in %s...", path, name)
+	return runEditor(profile, path, branch, false)
+}
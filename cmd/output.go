@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mskelton/pool/internal/logger"
+	"github.com/mskelton/pool/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var (
+	outputFormat string
+
+	// documentOut receives the JSON document. In JSON mode os.Stdout is
+	// pointed at stderr, so that logs, progress bars and the output of git,
+	// hooks and warm-up steps never end up in the document.
+	documentOut = os.Stdout
+)
+
+func jsonOutput() bool {
+	return outputFormat == output.JSON
+}
+
+func setupOutput() error {
+	switch outputFormat {
+	case output.Text:
+	case output.JSON:
+		documentOut = os.Stdout
+		os.Stdout = os.Stderr
+	default:
+		return fmt.Errorf("unknown output format %q, expected text or json", outputFormat)
+	}
+	return nil
+}
+
+// emit writes data as the JSON document of cmd. It does nothing in text mode.
+func emit(cmd *cobra.Command, data any) {
+	if !jsonOutput() {
+		return
+	}
+
+	if err := output.Write(documentOut, output.Document{Command: commandName(cmd), Data: data}); err != nil {
+		logger.Error("Failed to write output: %v", err)
+	}
+}
+
+// exitWithError reports err, as a JSON document in JSON mode, and exits.
+func exitWithError(cmd *cobra.Command, err error) {
+	if jsonOutput() {
+		output.Write(documentOut, output.Document{Command: commandName(cmd), Error: output.NewError(err)})
+	} else {
+		logger.Error("%v", err)
+	}
+	os.Exit(1)
+}
+
+// commandName names cmd in JSON documents, e.g. "config show". Claiming a
+// worktree with the root command is "claim".
+func commandName(cmd *cobra.Command) string {
+	if !cmd.HasParent() {
+		return "claim"
+	}
+	return strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/mskelton/pool/internal/errors"
+	"github.com/mskelton/pool/internal/git"
+	"github.com/spf13/cobra"
+)
+
+var pathCmd = &cobra.Command{
+	Use:   "path <branch>",
+	Short: "Print the path of a branch's worktree",
+	Long: `Print the path of the worktree for a branch. Exits with a non-zero status
+if the branch has no worktree.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeWorktreeBranches,
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := lookupWorktreePath(args[0])
+		if err != nil {
+			exitWithError(cmd, err)
+		}
+
+		if jsonOutput() {
+			emit(cmd, statusWorktree{Path: path, Branch: args[0]})
+			return
+		}
+		fmt.Println(path)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pathCmd)
+}
+
+func lookupWorktreePath(branch string) (string, error) {
+	repo, err := git.NewRepository(".")
+	if err != nil {
+		return "", err
+	}
+
+	manager, err := newManager(repo)
+	if err != nil {
+		return "", err
+	}
+
+	worktrees, err := repo.ListWorktrees()
+	if err != nil {
+		return "", err
+	}
+
+	path := findBranchWorktree(manager, worktrees, branch)
+	if path == "" {
+		return "", errors.Wrapf(errors.ErrBranchNotFound, "no worktree for %s", branch)
+	}
+
+	return path, nil
+}
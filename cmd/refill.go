@@ -2,39 +2,159 @@ package cmd
 
 import (
 	"os"
+	"os/exec"
+	"strconv"
 
+	"github.com/mskelton/pool/internal/errors"
 	"github.com/mskelton/pool/internal/git"
 	"github.com/mskelton/pool/internal/logger"
 	"github.com/mskelton/pool/internal/pool"
 	"github.com/spf13/cobra"
 )
 
+var (
+	refillBackground bool
+	refillRefresh    bool
+)
+
 var refillCmd = &cobra.Command{
 	Use:     "refill",
 	Aliases: []string{"fill"},
 	Short:   "Refill the worktree pool",
+	Long: `Refill the worktree pool.
+
+With --background the refill runs in a detached process that keeps running
+after this command exits. Its output is written to refill.log in the pool
+directory, and "pool status" shows whether it is still running.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := refillPool(); err != nil {
-			logger.Error("%v", err)
-			os.Exit(1)
+		var result *refillResult
+		var err error
+		if refillBackground {
+			result, err = startBackgroundRefill()
+		} else {
+			result, err = refillPool()
 		}
+
+		if err != nil {
+			exitWithError(cmd, err)
+		}
+		emit(cmd, result)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(refillCmd)
+	refillCmd.Flags().BoolVar(&refillBackground, "background", false, "Refill in a detached background process")
+	refillCmd.Flags().BoolVar(&refillRefresh, "refresh", false, "Also refresh available worktrees to the latest default branch")
+}
+
+// refillResult is the JSON document of pool refill.
+type refillResult struct {
+	PoolSize   int  `json:"pool_size"`
+	Available  int  `json:"available"`
+	Background bool `json:"background"`
+	// AlreadyRunning is set when another refill held the lock.
+	AlreadyRunning bool   `json:"already_running"`
+	PID            int    `json:"pid,omitempty"`
+	Log            string `json:"log,omitempty"`
+}
+
+func refillPool() (*refillResult, error) {
+	repo, err := git.NewRepository(".")
+	if err != nil {
+		return nil, err
+	}
+
+	manager, err := newManager(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := manager.AcquireRefillLock()
+	if errors.Is(err, errors.ErrPoolLocked) {
+		logger.Info("A refill is already running")
+		return refillSummary(manager, &refillResult{AlreadyRunning: true}), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	if refillRefresh {
+		manager.RefreshOnRefill = true
+	}
+
+	if err := manager.RunRefill(poolSize); err != nil {
+		return nil, err
+	}
+
+	return refillSummary(manager, &refillResult{}), nil
 }
 
-func refillPool() error {
+func startBackgroundRefill() (*refillResult, error) {
 	repo, err := git.NewRepository(".")
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	manager, err := newManager(repo)
+	if err != nil {
+		return nil, err
 	}
 
-	manager, err := pool.NewManager(repo)
+	pid, err := spawnRefill(manager, refillRefresh)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	logger.Info("Refilling pool in the background (pid %d, log: %s)", pid, manager.LogPath())
+	return refillSummary(manager, &refillResult{Background: true, PID: pid, Log: manager.LogPath()}), nil
+}
+
+func refillSummary(manager *pool.Manager, result *refillResult) *refillResult {
+	result.PoolSize, result.Available = manager.GetStatus()
+	return result
+}
+
+// spawnRefill starts pool refill in a detached process, which records its
+// run in the refill state and writes its output to the refill log.
+func spawnRefill(manager *pool.Manager, refresh bool) (int, error) {
+	args := []string{"refill", "--pool-size", strconv.Itoa(poolSize)}
+	if refresh {
+		args = append(args, "--refresh")
 	}
+	return spawnDetached(manager, args...)
+}
+
+// spawnDetached re-runs pool with args in a detached process whose output
+// goes to the pool's refill log.
+func spawnDetached(manager *pool.Manager, args ...string) (int, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to locate pool executable")
+	}
+
+	logFile, err := manager.OpenLog()
+	if err != nil {
+		return 0, err
+	}
+	defer logFile.Close()
+
+	if cfg.GetOffline() {
+		args = append(args, "--offline")
+	}
+
+	cmd := exec.Command(executable, args...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	detach(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return 0, errors.Wrap(err, "failed to start background process")
+	}
+
+	pid := cmd.Process.Pid
+	cmd.Process.Release()
 
-	return manager.Refill(poolSize)
+	return pid, nil
 }
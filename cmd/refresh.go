@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/mskelton/pool/internal/git"
+	"github.com/mskelton/pool/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+var refreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Update available pool worktrees to the latest default branch",
+	Long: `Fetch once and move every available pool worktree to the tip of the
+default branch, so that claiming one doesn't start from an old commit.
+
+Pool worktrees with local modifications are skipped.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runRefresh(); err != nil {
+			exitWithError(cmd, err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(refreshCmd)
+}
+
+func runRefresh() error {
+	repo, err := git.NewRepository(".")
+	if err != nil {
+		return err
+	}
+
+	manager, err := newManager(repo)
+	if err != nil {
+		return err
+	}
+
+	results, err := manager.Refresh()
+	if err != nil {
+		return err
+	}
+
+	if len(results) == 0 {
+		logger.Info("No available pool worktrees to refresh")
+		return nil
+	}
+
+	logger.Info("Refreshing pool worktrees to %s:", manager.RefreshTarget())
+
+	failed := 0
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			failed++
+			fmt.Printf("  %s %s: %v\n", color.RedString("✗"), result.Name, result.Err)
+		case result.Skipped != "":
+			fmt.Printf("  %s %s skipped: %s\n", color.YellowString("!"), result.Name, result.Skipped)
+		default:
+			fmt.Printf("  %s %s %s (%s)\n", color.GreenString("✓"), result.Name, result.Action, shortCommit(result.Base))
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to refresh %d pool worktrees", failed)
+	}
+
+	return nil
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mskelton/pool/internal/git"
+	"github.com/mskelton/pool/internal/hooks"
+	"github.com/mskelton/pool/internal/logger"
+	"github.com/mskelton/pool/internal/pool"
+	"github.com/spf13/cobra"
+)
+
+var releaseDeleteBranch bool
+
+var releaseCmd = &cobra.Command{
+	Use:   "release <branch>",
+	Short: "Recycle a finished worktree back into the pool",
+	Long: `Recycle the worktree of a finished branch back into the pool, so that
+the next refill doesn't need a new checkout.
+
+The worktree must be clean and unlocked, and its branch pushed or merged into
+the default branch. It is then detached, reset to the default branch and cleaned of
+untracked and ignored files, except those matching the "preserve" patterns
+in the config, and moved back into the pool.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeWorktreeBranches,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := releaseWorktree(args[0]); err != nil {
+			exitWithError(cmd, err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(releaseCmd)
+	releaseCmd.Flags().BoolVar(&releaseDeleteBranch, "delete-branch", false, "Also delete the local branch")
+}
+
+func releaseWorktree(branch string) error {
+	repo, err := git.NewRepository(".")
+	if err != nil {
+		return err
+	}
+
+	manager, err := newManager(repo)
+	if err != nil {
+		return err
+	}
+
+	worktrees, err := repo.ListWorktrees()
+	if err != nil {
+		return err
+	}
+
+	path := findBranchWorktree(manager, worktrees, branch)
+	if path == "" {
+		return fmt.Errorf("no worktree found for branch %s", branch)
+	}
+	wt, _ := findWorktree(manager, worktrees, path)
+
+	if len(worktrees) > 0 && canonicalPath(worktrees[0].Path) == canonicalPath(path) {
+		return fmt.Errorf("%s is the main worktree and cannot be released", path)
+	}
+
+	if strings.Contains(path, pool.PoolDir) {
+		return fmt.Errorf("%s is already a pool worktree", path)
+	}
+
+	if work := findLocalWork(repo, wt).Lines(); len(work) > 0 {
+		return fmt.Errorf("cannot release %s, it has\n%s", path, strings.Join(work, "\n"))
+	}
+
+	if err := runPreRemove(repo, wt); err != nil {
+		return hooks.Abort("release", err)
+	}
+
+	name, err := manager.Return(path)
+	if err != nil {
+		return err
+	}
+
+	if err := manager.ForgetPath(branch); err != nil {
+		logger.Warning("Failed to update recorded worktree paths: %v", err)
+	}
+
+	logger.Success("Released %s to the pool as %s", path, name)
+
+	if releaseDeleteBranch {
+		if err := repo.DeleteBranch(branch, true); err != nil {
+			return err
+		}
+		logger.Success("Deleted branch %s", branch)
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mskelton/pool/internal/git"
+	"github.com/mskelton/pool/internal/hooks"
+	"github.com/mskelton/pool/internal/logger"
+	"github.com/mskelton/pool/internal/pool"
+	"github.com/spf13/cobra"
+)
+
+type removeOptions struct {
+	Force        bool
+	DeleteBranch bool
+	ToPool       bool
+}
+
+var rmOptions removeOptions
+
+var rmCmd = &cobra.Command{
+	Use:     "rm <branch|path>",
+	Aliases: []string{"remove"},
+	Short:   "Remove a worktree",
+	Long: `Remove the worktree of a branch, or the worktree at a path.
+
+The worktree is kept if it has uncommitted or untracked changes, commits that
+are neither pushed nor merged into the default branch, or a lock, and what
+would be lost is listed. Use --force to remove it anyway.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeWorktreeBranches,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := removeTarget(args[0], rmOptions); err != nil {
+			exitWithError(cmd, err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rmCmd)
+	rmCmd.Flags().BoolVarP(&rmOptions.Force, "force", "f", false, "Remove even if work would be lost")
+	rmCmd.Flags().BoolVar(&rmOptions.DeleteBranch, "delete-branch", false, "Also delete the local branch")
+	rmCmd.Flags().BoolVar(&rmOptions.ToPool, "to-pool", false, "Recycle the worktree into the pool instead of deleting it")
+}
+
+// removeTarget removes the worktree of a branch, or at a path.
+func removeTarget(target string, opts removeOptions) error {
+	repo, err := git.NewRepository(".")
+	if err != nil {
+		return err
+	}
+
+	manager, err := newManager(repo)
+	if err != nil {
+		return err
+	}
+
+	worktrees, err := repo.ListWorktrees()
+	if err != nil {
+		return err
+	}
+
+	wt, ok := findWorktree(manager, worktrees, target)
+	if !ok {
+		return fmt.Errorf("no worktree found for %s", target)
+	}
+
+	if canonicalPath(worktrees[0].Path) == canonicalPath(wt.Path) {
+		return fmt.Errorf("%s is the main worktree and cannot be removed", wt.Path)
+	}
+
+	if strings.Contains(wt.Path, pool.PoolDir) {
+		return fmt.Errorf("%s is a pool worktree, use \"pool clean pool\" to reset it", wt.Path)
+	}
+
+	return removeWorktree(repo, manager, wt, opts)
+}
+
+// findWorktree finds the worktree of the branch target, or else the worktree
+// at the path target.
+func findWorktree(manager *pool.Manager, worktrees []git.Worktree, target string) (git.Worktree, bool) {
+	if path := findBranchWorktree(manager, worktrees, target); path != "" {
+		for _, wt := range worktrees {
+			if wt.Path == path {
+				return wt, true
+			}
+		}
+	}
+
+	if _, err := os.Stat(target); err == nil {
+		if abs, err := filepath.Abs(target); err == nil {
+			for _, wt := range worktrees {
+				if canonicalPath(wt.Path) == canonicalPath(abs) {
+					return wt, true
+				}
+			}
+		}
+	}
+
+	return git.Worktree{}, false
+}
+
+func removeWorktree(repo *git.Repository, manager *pool.Manager, wt git.Worktree, opts removeOptions) error {
+	if losses := findLocalWork(repo, wt).Lines(); len(losses) > 0 {
+		if !opts.Force {
+			return fmt.Errorf("refusing to remove %s, it has\n%s\nUse --force to remove it anyway", wt.Path, strings.Join(losses, "\n"))
+		}
+		logger.Warning("Removing %s anyway, discarding\n%s", wt.Path, strings.Join(losses, "\n"))
+	}
+
+	if err := runPreRemove(repo, wt); err != nil {
+		return hooks.Abort("removal", err)
+	}
+
+	if wt.Locked {
+		if err := repo.UnlockWorktree(wt.Path); err != nil {
+			return err
+		}
+	}
+
+	if opts.ToPool {
+		name, err := manager.Return(wt.Path)
+		if err != nil {
+			return err
+		}
+		logger.Success("Recycled %s into the pool as %s", wt.Path, name)
+	} else {
+		if err := repo.ForceRemoveWorktree(wt.Path); err != nil {
+			return err
+		}
+		logger.Success("Removed worktree: %s", wt.Path)
+	}
+
+	if wt.Branch == "" {
+		return nil
+	}
+
+	forgetPath(repo, wt.Branch)
+
+	if opts.DeleteBranch {
+		if err := repo.DeleteBranch(wt.Branch, true); err != nil {
+			return err
+		}
+		logger.Success("Deleted branch %s", wt.Branch)
+	}
+
+	return nil
+}
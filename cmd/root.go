@@ -3,15 +3,25 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/mskelton/pool/internal/config"
+	"github.com/mskelton/pool/internal/errors"
+	"github.com/mskelton/pool/internal/git"
+	"github.com/mskelton/pool/internal/hooks"
+	"github.com/mskelton/pool/internal/logger"
+	"github.com/mskelton/pool/internal/output"
+	"github.com/mskelton/pool/internal/pool"
 	"github.com/spf13/cobra"
 )
 
 var (
-	poolSize int
-	cfg      *config.Config
-	rootCmd  = &cobra.Command{
+	poolSize      int
+	refreshRemote bool
+	offline       bool
+	ephemeral     bool
+	cfg           *config.Config
+	rootCmd       = &cobra.Command{
 		Use:   "pool",
 		Short: "Fast worktree management with pre-seeded pool",
 		Long: `pool provides instant worktree creation by maintaining a pool of
@@ -19,20 +29,57 @@ pre-created worktrees that can be claimed and renamed on demand.`,
 		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) == 0 {
-				cmd.Help()
+				if err := runSwitch(cmd); err != nil {
+					exitWithError(cmd, err)
+				}
 				return
 			}
 
-			if err := createWorktree(args[0]); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+			if ephemeral && noOpen {
+				exitWithError(cmd, fmt.Errorf("--ephemeral waits for the editor and cannot be combined with --no-open"))
 			}
+
+			result, err := createWorktree(args[0])
+			if err != nil {
+				exitWithError(cmd, err)
+			}
+			if err := printPath(result.Path); err != nil {
+				exitWithError(cmd, err)
+			}
+			emit(cmd, result)
 		},
 	}
 )
 
+// Execute runs the command line. Errors are returned to be printed, except
+// in JSON mode where they are written as the error document.
 func Execute() error {
-	return rootCmd.Execute()
+	rootCmd.SilenceErrors = true
+
+	args, err := expandAliases(os.Args[1:], cfg.Aliases)
+	if err != nil {
+		return reportError(rootCmd, err)
+	}
+
+	rootCmd.SetArgs(args)
+	cmd, err := rootCmd.ExecuteC()
+	if err != nil {
+		return reportError(cmd, err)
+	}
+	return nil
+}
+
+func reportError(cmd *cobra.Command, err error) error {
+	// Alias and flag errors come before cobra has parsed --output, so look
+	// for it again, skipping the flags of other commands.
+	flags := rootCmd.PersistentFlags()
+	flags.ParseErrorsWhitelist.UnknownFlags = true
+	flags.Parse(os.Args[1:])
+
+	if jsonOutput() {
+		exitWithError(cmd, err)
+	}
+	return err
 }
 
 func init() {
@@ -42,13 +89,72 @@ func init() {
 		cfg = config.DefaultConfig()
 	}
 
+	rootCmd.ValidArgsFunction = completeBranches
+	rootCmd.Flags().BoolVar(&ephemeral, "ephemeral", false, "Return the worktree to the pool when the editor closes")
+	rootCmd.PersistentFlags().BoolVar(&noOpen, "no-open", false, "Don't open the worktree in the editor")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", output.Text, "Output format, text or json")
 	rootCmd.PersistentFlags().IntVar(&poolSize, "pool-size", cfg.PoolSize, "Number of pre-seeded worktrees")
+	rootCmd.PersistentFlags().BoolVar(&refreshRemote, "refresh-remote", false, "Fetch origin even if remote branches were fetched recently")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", cfg.GetOffline(), "Never fetch, resolve branches from local refs only")
+
+	rootCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]string{output.Text, output.JSON}, cobra.ShellCompDirectiveNoFileComp))
 
 	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		if err := setupOutput(); err != nil {
+			logger.Error("%v", err)
+			os.Exit(1)
+		}
+
 		if cmd.Flags().Changed("pool-size") {
 			cfg.PoolSize = poolSize
 		} else {
 			poolSize = cfg.PoolSize
 		}
+
+		if cmd.Flags().Changed("offline") {
+			cfg.Offline = &offline
+		}
+	}
+}
+
+func newManager(repo *git.Repository) (*pool.Manager, error) {
+	manager, err := pool.NewManager(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	manager.LockTimeout = cfg.GetLockTimeout()
+	manager.Warmup = cfg.Warmup
+	manager.RefreshOnRefill = cfg.GetRefreshOnRefill()
+	manager.Offline = cfg.GetOffline()
+	manager.Preserve = cfg.Preserve
+	manager.Hooks = newHookRunner(filepath.Dir(manager.Path()))
+	return manager, nil
+}
+
+func newHookRunner(repoRoot string) *hooks.Runner {
+	return hooks.NewRunner(repoRoot, cfg.Hooks, cfg.GetHookTimeout())
+}
+
+// updateRemoteRefs fetches origin when its remote-tracking refs are older than
+// remote_max_age, or always with --refresh-remote. Remote branch lookups are
+// answered from those refs. Nothing is fetched offline or without an origin.
+func updateRemoteRefs(repo *git.Repository) error {
+	if cfg.GetOffline() || !repo.HasRemote("origin") {
+		return nil
+	}
+	return repo.FetchIfStale(cfg.GetRemoteMaxAge(), refreshRemote)
+}
+
+// requireNetwork explains why a command that needs to reach origin cannot
+// run, e.g. requireNetwork(repo, "clean stale", "fetch origin to see which
+// branches were deleted").
+func requireNetwork(repo *git.Repository, command, need string) error {
+	if cfg.GetOffline() {
+		return errors.Wrapf(errors.ErrOffline, "%s needs to %s", command, need)
+	}
+	if !repo.HasRemote("origin") {
+		return errors.Wrapf(errors.ErrNoRemote, "%s needs to %s", command, need)
 	}
+	return nil
 }
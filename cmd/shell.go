@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mskelton/pool/internal/errors"
+	"github.com/spf13/cobra"
+)
+
+// printPathFile is set by the shell wrapper. The path of the worktree to cd
+// into is written there rather than to stdout, so that output, prompts and
+// editors are unaffected by the wrapper.
+var printPathFile string
+
+const posixShellInit = `pool() {
+  local pool_path_file pool_status pool_dir
+  pool_path_file="$(mktemp -t pool-path.XXXXXX)" || return
+  command pool --print-path "$pool_path_file" "$@"
+  pool_status=$?
+  pool_dir="$(cat "$pool_path_file")"
+  rm -f "$pool_path_file"
+  if [ -n "$pool_dir" ] && [ -d "$pool_dir" ]; then
+    cd "$pool_dir" || return
+  fi
+  return $pool_status
+}
+`
+
+const fishShellInit = `function pool --wraps pool
+    set -l pool_path_file (mktemp -t pool-path.XXXXXX); or return
+    command pool --print-path $pool_path_file $argv
+    set -l pool_status $status
+    set -l pool_dir (cat $pool_path_file)
+    rm -f $pool_path_file
+    if test -n "$pool_dir" -a -d "$pool_dir"
+        cd $pool_dir
+    end
+    return $pool_status
+end
+`
+
+var shellInitScripts = map[string]string{
+	"bash": posixShellInit,
+	"zsh":  posixShellInit,
+	"fish": fishShellInit,
+}
+
+var shellInitCmd = &cobra.Command{
+	Use:   "shell-init <bash|zsh|fish>",
+	Short: "Print a shell function that cds into claimed worktrees",
+	Long: `Print a shell function that wraps pool, so that claiming a worktree
+also changes the shell's directory to it. Add it to your shell config:
+
+  bash: eval "$(pool shell-init bash)"  in ~/.bashrc
+  zsh:  eval "$(pool shell-init zsh)"   in ~/.zshrc
+  fish: pool shell-init fish | source   in ~/.config/fish/config.fish`,
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Print(shellInitScripts[args[0]])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(shellInitCmd)
+	rootCmd.PersistentFlags().StringVar(&printPathFile, "print-path", "", "Write the path of the worktree to cd into to this file")
+	rootCmd.PersistentFlags().MarkHidden("print-path")
+}
+
+// printPath hands path to the shell wrapper, if there is one.
+func printPath(path string) error {
+	if printPathFile == "" {
+		return nil
+	}
+
+	if err := os.WriteFile(printPathFile, []byte(path), 0600); err != nil {
+		return errors.Wrap(err, "failed to write worktree path for the shell")
+	}
+	return nil
+}
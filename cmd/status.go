@@ -2,9 +2,9 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/mskelton/pool/internal/git"
@@ -18,9 +18,8 @@ var statusCmd = &cobra.Command{
 	Aliases: []string{"depth"},
 	Short:   "Show pool and worktree status",
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := showStatus(); err != nil {
-			logger.Error("%v", err)
-			os.Exit(1)
+		if err := showStatus(cmd); err != nil {
+			exitWithError(cmd, err)
 		}
 	},
 }
@@ -29,20 +28,49 @@ func init() {
 	rootCmd.AddCommand(statusCmd)
 }
 
-func showStatus() error {
+// statusReport is the JSON document of pool status.
+type statusReport struct {
+	PoolSize  int              `json:"pool_size"`
+	Available int              `json:"available"`
+	Entries   []statusEntry    `json:"entries"`
+	Refill    *refillReport    `json:"refill"`
+	Worktrees []statusWorktree `json:"worktrees"`
+}
+
+type statusEntry struct {
+	Name      string              `json:"name"`
+	Path      string              `json:"path"`
+	Status    pool.WorktreeStatus `json:"status"`
+	Claim     *pool.Claim         `json:"claim,omitempty"`
+	Refreshed *pool.RefreshRecord `json:"refreshed,omitempty"`
+}
+
+type refillReport struct {
+	Running    bool       `json:"running"`
+	PID        int        `json:"pid"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	Daemon     bool       `json:"daemon"`
+	Log        string     `json:"log"`
+}
+
+type statusWorktree struct {
+	Path   string `json:"path"`
+	Branch string `json:"branch"`
+}
+
+func showStatus(cmd *cobra.Command) error {
 	repo, err := git.NewRepository(".")
 	if err != nil {
 		return err
 	}
 
-	manager, err := pool.NewManager(repo)
+	manager, err := newManager(repo)
 	if err != nil {
 		return err
 	}
 
-	logger.Info("Worktree pool status:")
-	fmt.Println()
-
 	total, available := manager.GetStatus()
 
 	worktrees, err := repo.ListWorktrees()
@@ -50,30 +78,98 @@ func showStatus() error {
 		return err
 	}
 
+	refill, err := manager.RefillStatus()
+	if err != nil {
+		return err
+	}
+
+	report := statusReport{
+		PoolSize:  total,
+		Available: available,
+		Entries:   []statusEntry{},
+		Worktrees: []statusWorktree{},
+	}
+
 	for _, wt := range worktrees {
 		if strings.Contains(wt.Path, pool.PoolDir) {
 			name := filepath.Base(wt.Path)
 			if status, ok := manager.Status.Worktrees[name]; ok {
-				if status == pool.StatusAvailable {
-					fmt.Printf("  %s %s - available\n", color.GreenString("●"), name)
-				} else {
-					fmt.Printf("  %s %s - in use\n", color.RedString("●"), name)
-				}
+				report.Entries = append(report.Entries, statusEntry{
+					Name:      name,
+					Path:      wt.Path,
+					Status:    status,
+					Claim:     manager.Status.Claims[name],
+					Refreshed: manager.Status.Refreshed[name],
+				})
 			}
+		} else if !wt.Bare {
+			report.Worktrees = append(report.Worktrees, statusWorktree{Path: wt.Path, Branch: wt.Branch})
 		}
 	}
 
-	fmt.Println()
-	fmt.Printf("Pool size: %d\n", total)
-	fmt.Printf("Available: %d\n", available)
+	if refill != nil {
+		report.Refill = &refillReport{
+			Running:   refill.Running,
+			PID:       refill.PID,
+			StartedAt: refill.StartedAt,
+			Error:     refill.Error,
+			Daemon:    refill.Daemon,
+			Log:       manager.LogPath(),
+		}
+		if !refill.FinishedAt.IsZero() {
+			report.Refill.FinishedAt = &refill.FinishedAt
+		}
+	}
+
+	if jsonOutput() {
+		emit(cmd, report)
+		return nil
+	}
+
+	printStatus(report)
+	return nil
+}
+
+func printStatus(report statusReport) {
+	logger.Info("Worktree pool status:")
 	fmt.Println()
 
-	logger.Info("Active worktrees:")
-	for _, wt := range worktrees {
-		if !strings.Contains(wt.Path, pool.PoolDir) && !wt.Bare {
-			fmt.Printf("  %s (%s)\n", wt.Path, wt.Branch)
+	for _, entry := range report.Entries {
+		name := entry.Name
+		if entry.Status == pool.StatusAvailable && entry.Refreshed != nil {
+			fmt.Printf("  %s %s - available (at %s, refreshed %s)\n", color.GreenString("●"), name, shortCommit(entry.Refreshed.Base), entry.Refreshed.RefreshedAt.Format(time.RFC822))
+		} else if entry.Status == pool.StatusAvailable {
+			fmt.Printf("  %s %s - available\n", color.GreenString("●"), name)
+		} else if entry.Claim != nil && entry.Claim.Branch != "" {
+			fmt.Printf("  %s %s - in use (%s, pid %d)\n", color.RedString("●"), name, entry.Claim.Branch, entry.Claim.PID)
+		} else {
+			fmt.Printf("  %s %s - in use\n", color.RedString("●"), name)
 		}
 	}
 
-	return nil
+	fmt.Println()
+	fmt.Printf("Pool size: %d\n", report.PoolSize)
+	fmt.Printf("Available: %d\n", report.Available)
+
+	switch refill := report.Refill; {
+	case refill == nil:
+	case refill.Running:
+		fmt.Printf("Refill: %s (pid %d, started %s)\n", color.YellowString("running"), refill.PID, refill.StartedAt.Format(time.RFC822))
+	case refill.FinishedAt == nil:
+		fmt.Printf("Refill: %s (pid %d, started %s)\n", color.RedString("interrupted"), refill.PID, refill.StartedAt.Format(time.RFC822))
+		fmt.Printf("        see %s\n", refill.Log)
+	case refill.Daemon:
+		fmt.Printf("Refill: daemon stopped at %s\n", refill.FinishedAt.Format(time.RFC822))
+	case refill.Error != "":
+		fmt.Printf("Refill: %s at %s: %s\n", color.RedString("failed"), refill.FinishedAt.Format(time.RFC822), refill.Error)
+		fmt.Printf("        see %s\n", refill.Log)
+	default:
+		fmt.Printf("Refill: completed at %s\n", refill.FinishedAt.Format(time.RFC822))
+	}
+	fmt.Println()
+
+	logger.Info("Active worktrees:")
+	for _, wt := range report.Worktrees {
+		fmt.Printf("  %s (%s)\n", wt.Path, wt.Branch)
+	}
 }
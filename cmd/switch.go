@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/mskelton/pool/internal/git"
+	"github.com/mskelton/pool/internal/logger"
+	"github.com/mskelton/pool/internal/picker"
+	"github.com/mskelton/pool/internal/pool"
+	"github.com/spf13/cobra"
+)
+
+const pickerHeader = "● worktree * dirty ✓ merged · enter switch ^o open ^r release ^x remove"
+
+var switchCmd = &cobra.Command{
+	Use:   "switch",
+	Short: "Pick a worktree or branch to switch to",
+	Long: `Open a fuzzy finder over existing worktrees, local branches and remote
+branches. Worktrees are marked ●, dirty worktrees * and branches merged into
+the default branch ✓.
+
+  enter   switch to the worktree, claiming one for the branch if needed
+  ctrl-o  open the worktree in the editor
+  ctrl-r  release the worktree back into the pool
+  ctrl-x  remove the worktree
+  esc     cancel
+
+Running pool without a branch does the same in a terminal.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runSwitch(cmd); err != nil {
+			exitWithError(cmd, err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(switchCmd)
+}
+
+// interactive reports whether the picker can be shown.
+func interactive() bool {
+	return !jsonOutput() && isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stderr.Fd())
+}
+
+// pickerEntry is a worktree or branch offered by the picker. Path is empty
+// for branches without a worktree.
+type pickerEntry struct {
+	Branch string
+	Path   string
+}
+
+func runSwitch(cmd *cobra.Command) error {
+	if !interactive() {
+		return cmd.Help()
+	}
+
+	repo, err := git.NewRepository(".")
+	if err != nil {
+		return err
+	}
+
+	entries, items, err := pickerEntries(repo)
+	if err != nil {
+		return err
+	}
+
+	p := picker.New(items)
+	p.Header = pickerHeader
+
+	result, err := p.Run(os.Stdin, os.Stderr)
+	if err != nil || result == nil {
+		return err
+	}
+
+	entry := entries[result.Index]
+
+	switch result.Action {
+	case picker.Open:
+		if entry.Path == "" {
+			return fmt.Errorf("%s has no worktree to open", entry.Branch)
+		}
+		logger.Success("Opening %s...", entry.Path)
+		return openInEditor(entry.Path, entry.Branch)
+	case picker.Release:
+		if entry.Path == "" {
+			return fmt.Errorf("%s has no worktree to release", entry.Branch)
+		}
+		return releaseWorktree(entry.Branch)
+	case picker.Remove:
+		if entry.Path == "" {
+			return fmt.Errorf("%s has no worktree to remove", entry.Branch)
+		}
+		return removeTarget(entry.Path, removeOptions{})
+	}
+
+	claimed, err := createWorktree(entry.Branch)
+	if err != nil {
+		return err
+	}
+	return printPath(claimed.Path)
+}
+
+// pickerEntries lists the worktrees outside the pool, then local branches
+// without a worktree, then remote branches without a local branch.
+func pickerEntries(repo *git.Repository) ([]pickerEntry, []picker.Item, error) {
+	worktrees, err := repo.ListWorktrees()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	local, err := repo.LocalBranches()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	remote, err := repo.RemoteBranches()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	merged, err := repo.MergedLocalBranches(repo.DefaultBranchRef())
+	if err != nil {
+		logger.Warning("Failed to find merged branches: %v", err)
+	}
+
+	var entries []pickerEntry
+	var items []picker.Item
+	seen := make(map[string]bool)
+
+	mergedMark := func(branch string) string {
+		if branch != repo.DefaultBranch && slices.Contains(merged, branch) && repo.HasOwnCommits(branch) {
+			return "✓"
+		}
+		return ""
+	}
+
+	for _, wt := range worktrees {
+		if wt.Bare || wt.Prunable || wt.Branch == "" || strings.Contains(wt.Path, pool.PoolDir) {
+			continue
+		}
+
+		marks := "●"
+		if git.IsDirty(wt.Path) {
+			marks += "*"
+		}
+
+		seen[wt.Branch] = true
+		entries = append(entries, pickerEntry{Branch: wt.Branch, Path: wt.Path})
+		items = append(items, picker.Item{Text: wt.Branch, Marks: marks + mergedMark(wt.Branch), Detail: wt.Path})
+	}
+
+	for _, branch := range local {
+		if seen[branch] {
+			continue
+		}
+
+		seen[branch] = true
+		entries = append(entries, pickerEntry{Branch: branch})
+		items = append(items, picker.Item{Text: branch, Marks: mergedMark(branch), Detail: "local"})
+	}
+
+	for _, branch := range remote {
+		if seen[branch] {
+			continue
+		}
+
+		entries = append(entries, pickerEntry{Branch: branch})
+		items = append(items, picker.Item{Text: branch, Detail: "origin"})
+	}
+
+	return entries, items, nil
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mskelton/pool/internal/filesync"
+	"github.com/mskelton/pool/internal/git"
+	"github.com/mskelton/pool/internal/logger"
+	"github.com/mskelton/pool/internal/pool"
+	"github.com/spf13/cobra"
+)
+
+var (
+	syncDryRun bool
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync [path]",
+	Short: "Copy untracked files from the main worktree",
+	Long: `Apply the sync rules from the configuration to a worktree, copying or
+linking untracked files such as .env from the main worktree.
+
+Rules are applied automatically to new worktrees. Use this command to apply
+them to an existing worktree (the current directory by default) or to
+preview them with --dry-run.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dest := "."
+		if len(args) == 1 {
+			dest = args[0]
+		}
+
+		if err := runSync(dest); err != nil {
+			exitWithError(cmd, err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+	syncCmd.Flags().BoolVar(&syncDryRun, "dry-run", false, "Preview what would be synced")
+}
+
+func runSync(dest string) error {
+	repo, err := git.NewRepository(".")
+	if err != nil {
+		return err
+	}
+
+	dest, err = filepath.Abs(dest)
+	if err != nil {
+		return err
+	}
+
+	if len(cfg.Sync) == 0 {
+		logger.Info("No sync rules configured")
+		return nil
+	}
+
+	return syncFiles(repo, dest, syncDryRun)
+}
+
+// syncFiles applies the configured sync rules from the main worktree to
+// dest.
+func syncFiles(repo *git.Repository, dest string, preview bool) error {
+	if len(cfg.Sync) == 0 {
+		return nil
+	}
+
+	source, err := mainWorktreePath(repo)
+	if err != nil {
+		return err
+	}
+
+	if canonicalPath(source) == canonicalPath(dest) {
+		return fmt.Errorf("%s is the main worktree", dest)
+	}
+
+	actions, err := filesync.Plan(cfg.Sync, source, dest)
+	if err != nil {
+		return err
+	}
+
+	for _, action := range actions {
+		if preview || action.Skip != "" {
+			logger.Plain("  %s", action)
+		}
+	}
+
+	if preview {
+		logger.Warning("Dry run mode - no changes made")
+		return nil
+	}
+
+	if err := filesync.Apply(actions); err != nil {
+		return err
+	}
+
+	synced := 0
+	for _, action := range actions {
+		if action.Skip == "" {
+			synced++
+		}
+	}
+
+	if synced > 0 {
+		logger.Info("Synced %d files from %s", synced, source)
+	}
+
+	return nil
+}
+
+// mainWorktreePath returns the worktree that holds the default branch, or
+// the first non-bare worktree outside the pool.
+func mainWorktreePath(repo *git.Repository) (string, error) {
+	worktrees, err := repo.ListWorktrees()
+	if err != nil {
+		return "", err
+	}
+
+	var fallback string
+	for _, wt := range worktrees {
+		if wt.Bare || strings.Contains(wt.Path, pool.PoolDir) {
+			continue
+		}
+
+		if wt.Branch == repo.DefaultBranch {
+			return wt.Path, nil
+		}
+
+		if fallback == "" {
+			fallback = wt.Path
+		}
+	}
+
+	if fallback == "" {
+		return "", fmt.Errorf("no main worktree found to sync files from")
+	}
+
+	return fallback, nil
+}
+
+func canonicalPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/mskelton/pool/internal/git"
+)
+
+// localWork is what removing or recycling a worktree would lose. rm, release,
+// --ephemeral and clean all decide with it, so that they agree on what is
+// safe.
+type localWork struct {
+	// Changes are the uncommitted and untracked files, as short status lines.
+	Changes []string
+	// Commits are on no remote branch and not merged into the default branch.
+	Commits []string
+	Locked  bool
+	// LockReason is the reason given when the worktree was locked, if any.
+	LockReason string
+}
+
+// findLocalWork inspects wt. If its changes or commits can't be determined,
+// the error is reported in their place so that the worktree is never treated
+// as safe to remove.
+func findLocalWork(repo *git.Repository, wt git.Worktree) localWork {
+	work := localWork{Locked: wt.Locked, LockReason: wt.LockReason}
+
+	files, err := git.ChangedFiles(wt.Path)
+	if err != nil {
+		files = []string{fmt.Sprintf("(unknown: %v)", err)}
+	}
+	work.Changes = files
+
+	ref := wt.Branch
+	if ref == "" {
+		ref = wt.Commit
+	}
+
+	commits, err := repo.UnpushedCommits(ref)
+	if err != nil {
+		commits = []string{fmt.Sprintf("(unknown: %v)", err)}
+	} else if len(commits) > 0 {
+		// Commits that were merged, also by squash or rebase, are on the
+		// default branch already.
+		if how, err := mergeStatus(repo, ref); err == nil && how != "" {
+			commits = nil
+		}
+	}
+	work.Commits = commits
+
+	return work
+}
+
+// Lines lists the work as indented lines, or nothing if there is none.
+func (w localWork) Lines() []string {
+	var lines []string
+
+	if len(w.Changes) > 0 {
+		lines = append(lines, fmt.Sprintf("  %d uncommitted or untracked change(s):", len(w.Changes)))
+		for _, file := range w.Changes {
+			lines = append(lines, "    "+file)
+		}
+	}
+
+	if len(w.Commits) > 0 {
+		lines = append(lines, fmt.Sprintf("  %d unpushed and unmerged commit(s):", len(w.Commits)))
+		for _, commit := range w.Commits {
+			lines = append(lines, "    "+commit)
+		}
+	}
+
+	if w.Locked {
+		lines = append(lines, "  a lock"+w.lockReason())
+	}
+
+	return lines
+}
+
+func (w localWork) lockReason() string {
+	if w.LockReason == "" {
+		return ""
+	}
+	return ": " + w.LockReason
+}
+
+// mergeStatus reports how ref was merged into the default branch of origin
+// or the local default branch, whichever has it. A local branch that is
+// behind its remote branch is merged if the remote branch is, as squash
+// merges are made from the remote branch.
+func mergeStatus(repo *git.Repository, ref string) (git.MergeReason, error) {
+	bases := []string{repo.DefaultBranchRef()}
+	if bases[0] != repo.DefaultBranch {
+		bases = append(bases, repo.DefaultBranch)
+	}
+
+	refs := []string{ref}
+	if remote := "origin/" + ref; repo.RemoteBranchExists(ref) && repo.IsAncestor(ref, remote) {
+		refs = append(refs, remote)
+	}
+
+	for _, ref := range refs {
+		for _, base := range bases {
+			how, err := repo.MergeStatus(ref, base)
+			if err != nil || how != "" {
+				return how, err
+			}
+		}
+	}
+
+	return "", nil
+}
@@ -3,147 +3,337 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
-	"time"
 
+	"github.com/mskelton/pool/internal/errors"
 	"github.com/mskelton/pool/internal/git"
+	"github.com/mskelton/pool/internal/hooks"
 	"github.com/mskelton/pool/internal/logger"
 	"github.com/mskelton/pool/internal/pool"
 )
 
-func createWorktree(branchName string) error {
+// claimResult describes the worktree a branch was claimed into.
+type claimResult struct {
+	Branch    string `json:"branch"`
+	Path      string `json:"path"`
+	PoolEntry string `json:"pool_entry,omitempty"`
+	// Existing is set when the branch already had a worktree.
+	Existing bool `json:"existing"`
+	// Direct is set when the pool was empty and the worktree was created
+	// with git worktree add instead.
+	Direct bool `json:"direct"`
+}
+
+func createWorktree(branchName string) (*claimResult, error) {
+	if ephemeralMode() {
+		if err := checkEditorWaits(); err != nil {
+			return nil, err
+		}
+	}
+
 	repo, err := git.NewRepository(".")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	topLevel, err := repo.GetTopLevel()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	safeBranchName := strings.ReplaceAll(branchName, "/", "-")
-	worktreePath := filepath.Join(topLevel, safeBranchName)
+	worktreePath, err := cfg.WorktreePathFor(topLevel, branchName)
+	if err != nil {
+		return nil, err
+	}
 
 	logger.Info("Setting up worktree for branch: %s", branchName)
 
 	worktrees, err := repo.ListWorktrees()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	for _, wt := range worktrees {
-		if wt.Path == worktreePath {
-			logger.Warning("Worktree already exists at %s", worktreePath)
-			return openInEditor(worktreePath)
-		}
+	manager, err := newManager(repo)
+	if err != nil {
+		return nil, err
 	}
 
-	manager, err := pool.NewManager(repo)
-	if err != nil {
-		return err
+	if existing := findBranchWorktree(manager, worktrees, branchName); existing != "" {
+		logger.Warning("Worktree already exists at %s", existing)
+		if err := manager.TouchPath(branchName, existing); err != nil {
+			logger.Warning("Failed to record worktree use: %v", err)
+		}
+		return &claimResult{Branch: branchName, Path: existing, Existing: true}, openInEditor(existing, branchName)
 	}
 
-	poolPath, poolName, err := manager.GetAvailable()
-	if err != nil {
-		logger.Warning("No available worktrees in pool. Creating new worktree...")
-		return createWorktreeDirect(repo, worktreePath, branchName)
+	worktreePath = uniqueWorktreePath(manager, worktrees, worktreePath, branchName)
+
+	hookEnv := hooks.Env{
+		Branch:       branchName,
+		WorktreePath: worktreePath,
+		RepoRoot:     topLevel,
 	}
 
-	logger.Info("Using pool worktree: %s", poolName)
+	if err := manager.Hooks.Run(hooks.PreClaim, hookEnv); err != nil {
+		return nil, hooks.Abort("claim", err)
+	}
 
-	if err := manager.MarkInUse(poolName); err != nil {
-		return err
+	if err := updateRemoteRefs(repo); err != nil {
+		logger.Warning("Failed to fetch origin, using cached remote branches: %v", err)
 	}
 
-	if _, err := os.Stat(worktreePath); err == nil {
-		logger.Warning("Directory already exists at %s", worktreePath)
-		manager.MarkAvailable(poolName)
-		return openInEditor(worktreePath)
+	poolPath, poolName, err := manager.Claim(branchName)
+	if errors.Is(err, errors.ErrNoPoolAvailable) {
+		logger.Warning("No available worktrees in pool. Creating new worktree...")
+		if err := createWorktreeDirect(repo, worktreePath, branchName); err != nil {
+			return nil, err
+		}
+
+		recordPath(manager, branchName, worktreePath, "")
+		runPostClaim(manager, hookEnv)
+		startAutoRefill(manager)
+
+		result := &claimResult{Branch: branchName, Path: worktreePath, Direct: true}
+		if ephemeralMode() {
+			return result, runEphemeral(repo, manager, worktreePath, branchName)
+		}
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
 	}
 
+	logger.Info("Using pool worktree: %s", poolName)
+
 	if err := setupBranchInPool(repo, poolPath, branchName); err != nil {
 		manager.MarkAvailable(poolName)
-		return err
+		return nil, err
 	}
 
-	if err := repo.MoveWorktree(poolPath, worktreePath); err != nil {
+	manager.WarmUpClaimed(poolName, poolPath)
+
+	if err := os.MkdirAll(filepath.Dir(worktreePath), 0755); err != nil {
 		manager.MarkAvailable(poolName)
-		return err
+		return nil, errors.Wrap(err, "failed to create worktree directory")
+	}
+
+	if err := repo.MoveWorktree(poolPath, worktreePath); errors.Is(err, errors.ErrCrossDevice) {
+		logger.Warning("Cannot move %s to another filesystem, checking out %s at %s instead", poolName, branchName, worktreePath)
+		if err := replaceWorktree(repo, manager, poolPath, worktreePath, branchName); err != nil {
+			manager.Remove(poolName)
+			return nil, err
+		}
+	} else if err != nil {
+		// Hand the pool worktree back without the branch, so that the branch
+		// can be checked out again.
+		if detachErr := git.RunInDir(poolPath, "checkout", "--detach", "--force", repo.DefaultBranchRef()); detachErr == nil {
+			manager.MarkAvailable(poolName)
+		} else {
+			manager.Remove(poolName)
+		}
+		return nil, errors.Wrapf(err, "failed to move %s to %s", poolName, worktreePath)
 	}
 
 	repo.RepairWorktrees()
 
-	go refillPoolAsync(manager)
+	if err := syncFiles(repo, worktreePath, false); err != nil {
+		logger.Warning("%v", err)
+	}
 
-	logger.Success("Opening %s in VS Code...", worktreePath)
-	if err := openInEditor(worktreePath); err != nil {
-		return err
+	if err := manager.Remove(poolName); err != nil {
+		logger.Warning("Failed to update pool status: %v", err)
+	}
+
+	recordPath(manager, branchName, worktreePath, poolName)
+
+	hookEnv.Entry = poolName
+	runPostClaim(manager, hookEnv)
+	startAutoRefill(manager)
+
+	result := &claimResult{Branch: branchName, Path: worktreePath, PoolEntry: poolName}
+	if ephemeralMode() {
+		return result, runEphemeral(repo, manager, worktreePath, branchName)
+	}
+
+	if !noOpen {
+		logger.Success("Opening %s in %s...", worktreePath, cfg.Editor)
+	}
+	if err := openInEditor(worktreePath, branchName); err != nil {
+		return nil, err
 	}
 
 	fmt.Println()
 	logger.Info("Worktree ready at: %s", worktreePath)
 	logger.Info("Branch: %s", branchName)
 
+	return result, nil
+}
+
+// startAutoRefill refills the pool in the background after a claim, unless
+// auto_refill is off. It also runs when the pool was empty, so that the next
+// claim can use it.
+func startAutoRefill(manager *pool.Manager) {
+	if !cfg.GetAutoRefill() {
+		return
+	}
+	if _, err := spawnRefill(manager, false); err != nil {
+		logger.Warning("Failed to start background refill: %v", err)
+	}
+}
+
+func runPostClaim(manager *pool.Manager, env hooks.Env) {
+	if err := manager.Hooks.Run(hooks.PostClaim, env); err != nil {
+		logger.Warning("%v", err)
+	}
+}
+
+func recordPath(manager *pool.Manager, branch, path, entry string) {
+	if err := manager.RecordPath(branch, path, entry); err != nil {
+		logger.Warning("Failed to record worktree path: %v", err)
+	}
+}
+
+// findBranchWorktree returns the path of an existing worktree for branch,
+// preferring the path recorded when it was created.
+func findBranchWorktree(manager *pool.Manager, worktrees []git.Worktree, branch string) string {
+	if path, ok := manager.LookupPath(branch); ok {
+		for _, wt := range worktrees {
+			if wt.Branch == branch && canonicalPath(wt.Path) == canonicalPath(path) {
+				return wt.Path
+			}
+		}
+	}
+
+	for _, wt := range worktrees {
+		if wt.Branch == branch {
+			return wt.Path
+		}
+	}
+
+	return ""
+}
+
+// uniqueWorktreePath appends a numeric suffix to path while it is taken by
+// another worktree or directory, e.g. when feat/a-b and feat-a/b both slug
+// to feat-a-b.
+func uniqueWorktreePath(manager *pool.Manager, worktrees []git.Worktree, path, branch string) string {
+	taken := func(candidate string) bool {
+		if _, err := os.Stat(candidate); err == nil {
+			return true
+		}
+
+		for _, wt := range worktrees {
+			if canonicalPath(wt.Path) == canonicalPath(candidate) {
+				return true
+			}
+		}
+
+		owner, ok := manager.BranchAt(candidate)
+		return ok && owner != branch
+	}
+
+	candidate := path
+	for i := 2; taken(candidate); i++ {
+		candidate = fmt.Sprintf("%s-%d", path, i)
+	}
+
+	if candidate != path {
+		logger.Warning("%s is already taken, using %s", path, candidate)
+	}
+
+	return candidate
+}
+
+// replaceWorktree is the fallback for when git cannot move a claimed pool
+// worktree to its destination on another filesystem. The pool worktree is discarded and the branch is checked out
+// again at dest.
+func replaceWorktree(repo *git.Repository, manager *pool.Manager, poolPath, dest, branch string) error {
+	if err := repo.ForceRemoveWorktree(poolPath); err != nil {
+		return err
+	}
+
+	if err := repo.AddWorktreeForBranch(dest, branch); err != nil {
+		return err
+	}
+
+	manager.WarmUp(dest)
 	return nil
 }
 
 func createWorktreeDirect(repo *git.Repository, worktreePath, branchName string) error {
+	var err error
 	if repo.RemoteBranchExists(branchName) {
 		logger.Info("Branch exists remotely, checking out...")
-		return repo.AddWorktreeFromBranch(worktreePath, branchName, fmt.Sprintf("origin/%s", branchName))
+		err = repo.AddWorktreeFromBranch(worktreePath, branchName, fmt.Sprintf("origin/%s", branchName))
+	} else {
+		logger.Info("Creating new branch...")
+		err = repo.AddWorktree(worktreePath, branchName)
+	}
+	if err != nil {
+		return err
 	}
 
-	logger.Info("Creating new branch...")
-	return repo.AddWorktree(worktreePath, branchName)
+	if err := syncFiles(repo, worktreePath, false); err != nil {
+		logger.Warning("%v", err)
+	}
+
+	return nil
 }
 
 func setupBranchInPool(repo *git.Repository, poolPath, branchName string) error {
-	// Fetch latest changes
-	if err := git.RunInDir(poolPath, "fetch", "origin"); err != nil {
+	poolRepo, err := git.NewRepository(poolPath)
+	if err != nil {
 		return err
 	}
 
+	if repo.BranchExists(branchName) {
+		logger.Info("Checking out local branch...")
+		return poolRepo.CheckoutBranch(branchName, false)
+	}
+
 	if repo.RemoteBranchExists(branchName) {
 		logger.Info("Checking out existing branch...")
-		return repo.CheckoutNewBranch(branchName, fmt.Sprintf("origin/%s", branchName))
+		return poolRepo.CheckoutNewBranch(branchName, fmt.Sprintf("origin/%s", branchName))
 	}
 
 	logger.Info("Creating new branch...")
-	return repo.CheckoutNewBranch(branchName, repo.DefaultBranch)
+	return poolRepo.StartBranch(branchName, repo.DefaultBranchRef())
 }
 
-func openInEditor(path string) error {
-	editor := cfg.Editor
-	if editor == "" {
-		editor = "code"
+// runEphemeral opens a newly created worktree, waits for the editor to close
+// and then hands the worktree back to the pool, unless it holds work that
+// would be lost.
+func runEphemeral(repo *git.Repository, manager *pool.Manager, worktreePath, branchName string) error {
+	logger.Info("Opening %s, it returns to the pool when the editor closes...", worktreePath)
+	if err := openInEditorAndWait(worktreePath, branchName); err != nil {
+		return err
 	}
 
-	var cmd *exec.Cmd
-	switch editor {
-	case "code", "subl", "atom":
-		cmd = exec.Command(editor, ".")
-	case "vim", "nvim", "emacs":
-		cmd = exec.Command(editor)
-	default:
-		cmd = exec.Command(editor, ".")
+	worktrees, err := repo.ListWorktrees()
+	if err != nil {
+		return err
 	}
 
-	cmd.Dir = path
-	return cmd.Run()
-}
+	wt, ok := findWorktree(manager, worktrees, worktreePath)
+	if !ok {
+		return fmt.Errorf("%s is no longer a worktree", worktreePath)
+	}
 
-func refillPoolAsync(manager *pool.Manager) {
-	time.Sleep(2 * time.Second)
+	if work := findLocalWork(repo, wt).Lines(); len(work) > 0 {
+		logger.Warning("Keeping %s, it has\n%s", worktreePath, strings.Join(work, "\n"))
+		return nil
+	}
 
-	size := poolSize
-	if size == 0 {
-		size = pool.DefaultPoolSize
+	name, err := manager.Return(worktreePath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to return %s to the pool", worktreePath)
 	}
 
-	if err := manager.Refill(size); err != nil {
-		logger.Error("Failed to refill pool: %v", err)
+	if err := manager.ForgetPath(branchName); err != nil {
+		logger.Warning("Failed to update recorded worktree paths: %v", err)
 	}
+
+	logger.Success("Returned %s to the pool as %s", worktreePath, name)
+	return nil
 }
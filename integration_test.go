@@ -1,12 +1,16 @@
 package main
 
 import (
+	"encoding/json"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/mskelton/pool/internal/config"
 	"github.com/mskelton/pool/internal/git"
 	"github.com/mskelton/pool/internal/pool"
 )
@@ -197,6 +201,605 @@ func TestPoolCLI(t *testing.T) {
 			t.Errorf("Expected available count in output: %s", output)
 		}
 	})
+
+	t.Run("JSONOutput", func(t *testing.T) {
+		cmd := exec.Command(poolBinary, "status", "--output", "json")
+		cmd.Dir = tmpDir
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("pool status --output json failed: %v", err)
+		}
+
+		var doc struct {
+			Version int    `json:"version"`
+			Command string `json:"command"`
+			Data    struct {
+				PoolSize  int `json:"pool_size"`
+				Available int `json:"available"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(output, &doc); err != nil {
+			t.Fatalf("Expected a single JSON document on stdout: %v\nOutput: %s", err, output)
+		}
+
+		if doc.Version != 1 || doc.Command != "status" || doc.Data.PoolSize != 2 || doc.Data.Available != 2 {
+			t.Errorf("Unexpected status document: %s", output)
+		}
+
+		cmd = exec.Command(poolBinary, "clean", "bogus", "-o", "json")
+		cmd.Dir = tmpDir
+		output, _ = cmd.Output()
+
+		var errDoc struct {
+			Error *struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(output, &errDoc); err != nil || errDoc.Error == nil {
+			t.Fatalf("Expected a JSON error document: %v\nOutput: %s", err, output)
+		}
+
+		if !strings.Contains(errDoc.Error.Message, "unknown clean type") {
+			t.Errorf("Unexpected error document: %s", output)
+		}
+
+		for _, args := range [][]string{
+			{"-o", "json", "config", "set", "lock_timeout", "nope"},
+			{"-o", "json", "clean"},
+			{"--bogus", "-o", "json", "status"},
+		} {
+			cmd = exec.Command(poolBinary, args...)
+			cmd.Dir = tmpDir
+			output, err = cmd.Output()
+			if err == nil {
+				t.Errorf("Expected pool %v to fail", args)
+			}
+
+			errDoc.Error = nil
+			if err := json.Unmarshal(output, &errDoc); err != nil || errDoc.Error == nil {
+				t.Errorf("Expected a JSON error document for %v: %v\nOutput: %s", args, err, output)
+			}
+		}
+	})
+
+	t.Run("StatusAfterDaemonStop", func(t *testing.T) {
+		daemon := exec.Command(poolBinary, "daemon", "--pool-size", "2", "--interval", "1s")
+		daemon.Dir = tmpDir
+		if err := daemon.Start(); err != nil {
+			t.Fatal(err)
+		}
+
+		var output []byte
+		for range 50 {
+			cmd := exec.Command(poolBinary, "daemon", "--stop")
+			cmd.Dir = tmpDir
+			output, _ = cmd.CombinedOutput()
+			if strings.Contains(string(output), "Stopped refill daemon") {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		if !strings.Contains(string(output), "Stopped refill daemon") {
+			daemon.Process.Kill()
+			t.Fatalf("Expected the daemon to be stopped: %s", output)
+		}
+
+		if err := daemon.Wait(); err != nil {
+			t.Fatalf("Expected the daemon to exit cleanly: %v", err)
+		}
+
+		cmd := exec.Command(poolBinary, "status")
+		cmd.Dir = tmpDir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("pool status failed: %v\nOutput: %s", err, output)
+		}
+
+		if !strings.Contains(string(output), "daemon stopped") {
+			t.Errorf("Expected the stopped daemon in output: %s", output)
+		}
+	})
+
+	t.Run("EphemeralNeedsWaitingEditor", func(t *testing.T) {
+		cmd := exec.Command(poolBinary, "feat-ephemeral", "--ephemeral")
+		cmd.Dir = tmpDir
+		cmd.Env = append(os.Environ(), "POOL_EDITOR=code-insiders")
+		output, err := cmd.CombinedOutput()
+		if err == nil || !strings.Contains(string(output), "wait_flag") {
+			t.Errorf("Expected --ephemeral to be refused for an editor that doesn't wait: %s", output)
+		}
+
+		cmd = exec.Command("git", "branch", "--list", "feat-ephemeral")
+		cmd.Dir = tmpDir
+		if output, _ := cmd.Output(); len(output) > 0 {
+			t.Errorf("Expected no branch to be created, got %s", output)
+		}
+	})
+
+	t.Run("NoArgsWithoutTerminal", func(t *testing.T) {
+		cmd := exec.Command(poolBinary)
+		cmd.Dir = tmpDir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("pool failed: %v\nOutput: %s", err, output)
+		}
+
+		if !strings.Contains(string(output), "Usage:") {
+			t.Errorf("Expected help without a terminal: %s", output)
+		}
+	})
+
+	t.Run("PathCommand", func(t *testing.T) {
+		cmd := exec.Command("git", "branch", "--show-current")
+		cmd.Dir = tmpDir
+		branch, err := cmd.Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd = exec.Command(poolBinary, "path", strings.TrimSpace(string(branch)))
+		cmd.Dir = tmpDir
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("pool path failed: %v", err)
+		}
+
+		got, _ := filepath.EvalSymlinks(strings.TrimSpace(string(output)))
+		want, _ := filepath.EvalSymlinks(tmpDir)
+		if got != want {
+			t.Errorf("Expected path %s, got %s", want, got)
+		}
+
+		cmd = exec.Command(poolBinary, "path", "no-such-branch")
+		cmd.Dir = tmpDir
+		if output, err := cmd.CombinedOutput(); err == nil {
+			t.Errorf("Expected pool path to fail for a branch without a worktree: %s", output)
+		}
+	})
+
+	t.Run("ShellInitCommand", func(t *testing.T) {
+		for _, shell := range []string{"bash", "zsh", "fish"} {
+			cmd := exec.Command(poolBinary, "shell-init", shell)
+			output, err := cmd.Output()
+			if err != nil {
+				t.Fatalf("pool shell-init %s failed: %v", shell, err)
+			}
+
+			if !strings.Contains(string(output), "--print-path") {
+				t.Errorf("Expected %s wrapper to use --print-path: %s", shell, output)
+			}
+		}
+
+		if output, err := exec.Command(poolBinary, "shell-init", "tcsh").CombinedOutput(); err == nil {
+			t.Errorf("Expected unsupported shell to fail: %s", output)
+		}
+	})
+
+	t.Run("AliasCommand", func(t *testing.T) {
+		cmd := exec.Command(poolBinary, "alias", "add", "st", "status")
+		cmd.Dir = tmpDir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("pool alias add failed: %v\nOutput: %s", err, output)
+		}
+
+		cmd = exec.Command(poolBinary, "st")
+		cmd.Dir = tmpDir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("pool st failed: %v\nOutput: %s", err, output)
+		}
+
+		if !strings.Contains(string(output), "Pool size: 2") {
+			t.Errorf("Expected alias to expand to status: %s", output)
+		}
+
+		cmd = exec.Command(poolBinary, "alias", "add", "status", "st")
+		cmd.Dir = tmpDir
+		if output, err := cmd.CombinedOutput(); err == nil {
+			t.Errorf("Expected alias shadowing a built-in command to fail: %s", output)
+		}
+
+		cmd = exec.Command(poolBinary, "alias", "add", "status-alias", "st-loop")
+		cmd.Dir = tmpDir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("pool alias add failed: %v\nOutput: %s", err, output)
+		}
+
+		cmd = exec.Command(poolBinary, "alias", "add", "st-loop", "status-alias")
+		cmd.Dir = tmpDir
+		output, err = cmd.CombinedOutput()
+		if err == nil || !strings.Contains(string(output), "recursive alias") {
+			t.Errorf("Expected recursive alias to be rejected: %s", output)
+		}
+	})
+
+	t.Run("ConfigSetChangesOnlyOneFile", func(t *testing.T) {
+		homeDir := t.TempDir()
+		global := `{"hooks": {"post-claim": ["true"]}, "aliases": {"gl": "list"}}`
+		if err := os.WriteFile(filepath.Join(homeDir, ".poolrc"), []byte(global), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := exec.Command(poolBinary, "config", "set", "offline", "false")
+		cmd.Dir = tmpDir
+		cmd.Env = append(os.Environ(), "HOME="+homeDir)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("pool config set failed: %v\nOutput: %s", err, output)
+		}
+
+		data, err := os.ReadFile(filepath.Join(tmpDir, ".poolrc.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var local map[string]any
+		if err := json.Unmarshal(data, &local); err != nil {
+			t.Fatal(err)
+		}
+
+		if local["offline"] != false {
+			t.Errorf("Expected offline to be saved as false, got %s", data)
+		}
+		if _, ok := local["hooks"]; ok {
+			t.Errorf("Expected global hooks not to be copied into the local config, got %s", data)
+		}
+		if aliases, _ := local["aliases"].(map[string]any); aliases["gl"] != nil || aliases["st"] == nil {
+			t.Errorf("Expected only the local aliases to be kept, got %s", data)
+		}
+	})
+
+	t.Run("LocalConfigKeepsDefaults", func(t *testing.T) {
+		for _, args := range [][]string{{"alias", "add", "l", "list"}, {"config", "set", "editor", "vim"}} {
+			cmd := exec.Command(poolBinary, args...)
+			cmd.Dir = tmpDir
+			if output, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("pool %v failed: %v\nOutput: %s", args, err, output)
+			}
+		}
+
+		cmd := exec.Command(poolBinary, "config", "show", "-o", "json")
+		cmd.Dir = tmpDir
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("pool config show failed: %v", err)
+		}
+
+		var doc struct {
+			Data struct {
+				AutoRefill *bool  `json:"auto_refill"`
+				Editor     string `json:"editor"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(output, &doc); err != nil {
+			t.Fatal(err)
+		}
+
+		if doc.Data.AutoRefill == nil || !*doc.Data.AutoRefill || doc.Data.Editor != "vim" {
+			t.Errorf("Expected auto refill to stay on next to the local settings, got %s", output)
+		}
+	})
+
+	t.Run("Completion", func(t *testing.T) {
+		complete := func(args ...string) []string {
+			cmd := exec.Command(poolBinary, append([]string{"__complete"}, args...)...)
+			cmd.Dir = tmpDir
+			output, err := cmd.Output()
+			if err != nil {
+				t.Fatalf("pool __complete %v failed: %v", args, err)
+			}
+
+			var completions []string
+			for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+				if !strings.HasPrefix(line, ":") {
+					completions = append(completions, strings.SplitN(line, "\t", 2)[0])
+				}
+			}
+			return completions
+		}
+
+		cmd := exec.Command("git", "branch", "--show-current")
+		cmd.Dir = tmpDir
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		branch := strings.TrimSpace(string(output))
+
+		configPath := filepath.Join(tmpDir, config.ConfigFileName)
+		localConfig, err := config.ReadFile(configPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		localConfig.Editors = map[string]config.EditorProfile{"work": {Command: "code --profile work {path}"}}
+		if err := localConfig.Save(configPath); err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			args []string
+			want string
+		}{
+			{[]string{""}, branch},
+			{[]string{""}, "status"},
+			{[]string{"s"}, "st"},
+			{[]string{"path", ""}, branch},
+			{[]string{"clean", "me"}, "merged"},
+			{[]string{"config", "set", "off"}, "offline"},
+			{[]string{"config", "set", "auto_refill", ""}, "true"},
+			{[]string{"config", "set", "editor", ""}, "nvim"},
+			{[]string{"config", "set", "editor", ""}, "work"},
+			{[]string{"alias", "rm", ""}, "status-alias"},
+			{[]string{"--output", ""}, "json"},
+		}
+
+		for _, tt := range tests {
+			completions := complete(tt.args...)
+			if !slices.Contains(completions, tt.want) {
+				t.Errorf("Expected completions for %q to contain %s, got %v", tt.args, tt.want, completions)
+			}
+		}
+
+		if completions := complete("clean", "me"); slices.Contains(completions, "stale") {
+			t.Errorf("Expected completions to be filtered by prefix, got %v", completions)
+		}
+
+		output, err = exec.Command(poolBinary, "completion", "zsh").Output()
+		if err != nil {
+			t.Fatalf("pool completion zsh failed: %v", err)
+		}
+
+		if !strings.Contains(string(output), "__complete") {
+			t.Errorf("Expected completion script to use dynamic completions")
+		}
+	})
+
+	t.Run("RmCommand", func(t *testing.T) {
+		worktreePath := filepath.Join(tmpDir, "rm-test")
+		cmd := exec.Command("git", "worktree", "add", "-b", "rm-test", worktreePath)
+		cmd.Dir = tmpDir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git worktree add failed: %v\nOutput: %s", err, output)
+		}
+
+		if err := os.WriteFile(filepath.Join(worktreePath, "notes.txt"), []byte("notes"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd = exec.Command(poolBinary, "rm", "rm-test")
+		cmd.Dir = tmpDir
+		output, err := cmd.CombinedOutput()
+		if err == nil || !strings.Contains(string(output), "notes.txt") {
+			t.Fatalf("Expected pool rm to refuse and list the untracked file: %s", output)
+		}
+
+		cmd = exec.Command(poolBinary, "rm", "rm-test", "--force", "--delete-branch")
+		cmd.Dir = tmpDir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("pool rm --force failed: %v\nOutput: %s", err, output)
+		}
+
+		if _, err := os.Stat(worktreePath); !os.IsNotExist(err) {
+			t.Error("Expected worktree directory to be removed")
+		}
+
+		cmd = exec.Command("git", "rev-parse", "--verify", "--quiet", "refs/heads/rm-test")
+		cmd.Dir = tmpDir
+		if err := cmd.Run(); err == nil {
+			t.Error("Expected branch rm-test to be deleted")
+		}
+	})
+
+	t.Run("CleanProtectsUnpushedWork", func(t *testing.T) {
+		repoDir := filepath.Join(tmpDir, "..", filepath.Base(tmpDir)+"-clean")
+		originDir := repoDir + "-origin"
+		defer os.RemoveAll(repoDir)
+		defer os.RemoveAll(originDir)
+
+		git := func(dir string, args ...string) {
+			t.Helper()
+			cmd := exec.Command("git", args...)
+			cmd.Dir = dir
+			if output, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v failed: %v\nOutput: %s", args, err, output)
+			}
+		}
+
+		if err := os.MkdirAll(repoDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := initTestRepo(repoDir); err != nil {
+			t.Fatal(err)
+		}
+
+		git(tmpDir, "init", "--bare", originDir)
+		git(repoDir, "remote", "add", "origin", originDir)
+		git(repoDir, "push", "origin", "HEAD")
+
+		for _, branch := range []string{"pushed", "unpushed"} {
+			git(repoDir, "worktree", "add", "-b", branch, filepath.Join(repoDir, branch))
+			git(filepath.Join(repoDir, branch), "commit", "--allow-empty", "-m", branch+" work")
+			git(repoDir, "push", "origin", branch)
+		}
+		git(filepath.Join(repoDir, "unpushed"), "commit", "--allow-empty", "-m", "local only")
+		git(repoDir, "push", "origin", "--delete", "pushed", "unpushed")
+
+		cmd := exec.Command(poolBinary, "clean", "stale", "--refresh-remote")
+		cmd.Dir = repoDir
+		cmd.Stdin = strings.NewReader("y\ny\n")
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("pool clean stale failed: %v\nOutput: %s", err, output)
+		}
+
+		if !strings.Contains(string(output), "local only") {
+			t.Errorf("Expected unpushed commit to be listed: %s", output)
+		}
+
+		if _, err := os.Stat(filepath.Join(repoDir, "unpushed")); err != nil {
+			t.Errorf("Expected worktree with unpushed work to be kept: %s", output)
+		}
+
+		cmd = exec.Command(poolBinary, "clean", "stale", "--include-unpushed")
+		cmd.Dir = repoDir
+		cmd.Stdin = strings.NewReader("y\ny\n")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("pool clean stale --include-unpushed failed: %v\nOutput: %s", err, output)
+		}
+
+		if _, err := os.Stat(filepath.Join(repoDir, "unpushed")); !os.IsNotExist(err) {
+			t.Error("Expected worktree to be removed with --include-unpushed")
+		}
+	})
+
+	t.Run("CleanMergedDeletesLocalBranch", func(t *testing.T) {
+		repoDir := filepath.Join(tmpDir, "..", filepath.Base(tmpDir)+"-merged")
+		defer os.RemoveAll(repoDir)
+
+		git := func(dir string, args ...string) string {
+			t.Helper()
+			cmd := exec.Command("git", args...)
+			cmd.Dir = dir
+			output, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("git %v failed: %v\nOutput: %s", args, err, output)
+			}
+			return string(output)
+		}
+
+		if err := os.MkdirAll(repoDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := initTestRepo(repoDir); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, branch := range []string{"done", "wip"} {
+			worktree := filepath.Join(repoDir, branch)
+			git(repoDir, "worktree", "add", "-b", branch, worktree)
+			if err := os.WriteFile(filepath.Join(worktree, branch+".txt"), []byte(branch), 0644); err != nil {
+				t.Fatal(err)
+			}
+			git(worktree, "add", ".")
+			git(worktree, "commit", "-m", branch)
+		}
+		git(repoDir, "merge", "--squash", "done")
+		git(repoDir, "commit", "-m", "Squashed done")
+
+		cmd := exec.Command(poolBinary, "clean", "merged")
+		cmd.Dir = repoDir
+		cmd.Stdin = strings.NewReader("y\ny\n")
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("pool clean merged failed: %v\nOutput: %s", err, output)
+		}
+
+		if !strings.Contains(string(output), "squash-merged") || !strings.Contains(string(output), "Deleted 1 branch(es)") {
+			t.Errorf("Expected squash merge and summary in output: %s", output)
+		}
+
+		if _, err := os.Stat(filepath.Join(repoDir, "done")); !os.IsNotExist(err) {
+			t.Error("Expected worktree of merged branch to be removed")
+		}
+
+		branches := git(repoDir, "branch", "--format=%(refname:short)")
+		if strings.Contains(branches, "done") || !strings.Contains(branches, "wip") {
+			t.Errorf("Expected only the merged branch to be deleted, got %s", branches)
+		}
+	})
+
+	t.Run("ClaimStartsAtOriginDefaultBranch", func(t *testing.T) {
+		upstreamDir := filepath.Join(tmpDir, "..", filepath.Base(tmpDir)+"-upstream")
+		cloneDir := filepath.Join(tmpDir, "..", filepath.Base(tmpDir)+"-clone")
+		defer os.RemoveAll(upstreamDir)
+		defer os.RemoveAll(cloneDir)
+
+		run := func(dir, name string, args ...string) string {
+			t.Helper()
+			cmd := exec.Command(name, args...)
+			cmd.Dir = dir
+			output, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("%s %v failed: %v\nOutput: %s", name, args, err, output)
+			}
+			return strings.TrimSpace(string(output))
+		}
+
+		if err := os.MkdirAll(upstreamDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := initTestRepo(upstreamDir); err != nil {
+			t.Fatal(err)
+		}
+		run(tmpDir, "git", "clone", "-q", upstreamDir, cloneDir)
+		run(upstreamDir, "git", "commit", "--allow-empty", "-m", "Second commit")
+		tip := run(upstreamDir, "git", "rev-parse", "HEAD")
+
+		if err := os.WriteFile(filepath.Join(cloneDir, ".poolrc.json"), []byte(`{"auto_refill": false}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		run(cloneDir, poolBinary, "init", "--pool-size", "1")
+		run(cloneDir, poolBinary, "feat-r", "--no-open")
+
+		if head := run(cloneDir, "git", "rev-parse", "feat-r"); head != tip {
+			t.Errorf("Expected feat-r to start at origin's tip %s, got %s", tip, head)
+		}
+
+		cmd := exec.Command("git", "config", "branch.feat-r.merge")
+		cmd.Dir = cloneDir
+		if output, err := cmd.Output(); err == nil {
+			t.Errorf("Expected feat-r not to track the default branch, got %s", output)
+		}
+	})
+
+	t.Run("CleanOld", func(t *testing.T) {
+		repoDir := filepath.Join(tmpDir, "..", filepath.Base(tmpDir)+"-old")
+		defer os.RemoveAll(repoDir)
+
+		git := func(dir string, env []string, args ...string) {
+			t.Helper()
+			cmd := exec.Command("git", args...)
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(), env...)
+			if output, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v failed: %v\nOutput: %s", args, err, output)
+			}
+		}
+
+		if err := os.MkdirAll(repoDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := initTestRepo(repoDir); err != nil {
+			t.Fatal(err)
+		}
+
+		// Both branches start from commits on the default branch, so removing
+		// their worktrees loses no work.
+		dates := map[string]string{"older": "2020-01-01T00:00:00", "old": "2021-01-01T00:00:00"}
+		for _, branch := range []string{"older", "old"} {
+			git(repoDir, []string{"GIT_COMMITTER_DATE=" + dates[branch]}, "commit", "--allow-empty", "-m", branch)
+			git(repoDir, nil, "worktree", "add", "-b", branch, filepath.Join(repoDir, branch))
+		}
+		git(repoDir, nil, "worktree", "add", "-b", "new", filepath.Join(repoDir, "new"), "HEAD~2")
+
+		cmd := exec.Command(poolBinary, "clean", "old", "--older-than", "30d", "--keep", "2")
+		cmd.Dir = repoDir
+		cmd.Stdin = strings.NewReader("y\ny\ny\n")
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("pool clean old failed: %v\nOutput: %s", err, output)
+		}
+
+		for branch, kept := range map[string]bool{"new": true, "old": true, "older": false} {
+			_, err := os.Stat(filepath.Join(repoDir, branch))
+			if kept && err != nil {
+				t.Errorf("Expected worktree %s to be kept: %s", branch, output)
+			}
+			if !kept && !os.IsNotExist(err) {
+				t.Errorf("Expected worktree %s to be removed: %s", branch, output)
+			}
+		}
+	})
 }
 
 func initTestRepo(dir string) error {
@@ -7,8 +7,10 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/mskelton/pool/internal/errors"
+	"github.com/mskelton/pool/internal/hooks"
 )
 
 const (
@@ -16,14 +18,42 @@ const (
 	GlobalConfigFileName = ".poolrc"
 )
 
+// WarmupStep runs a command in new pool worktrees whenever the file named by
+// When changes, e.g. {"when": "package-lock.json", "run": "npm ci"}.
+type WarmupStep struct {
+	When string `json:"when"`
+	Run  string `json:"run"`
+}
+
+// SyncRule copies or links untracked files matching Pattern from the main
+// worktree into new worktrees. Strategy is one of copy (default), symlink or
+// reflink, and Overwrite is one of never (default), always or newer.
+type SyncRule struct {
+	Pattern   string `json:"pattern"`
+	Strategy  string `json:"strategy,omitempty"`
+	Overwrite string `json:"overwrite,omitempty"`
+}
+
 type Config struct {
-	PoolSize      int               `json:"pool_size,omitempty"`
-	PoolPrefix    string            `json:"pool_prefix,omitempty"`
-	DefaultBranch string            `json:"default_branch,omitempty"`
-	Editor        string            `json:"editor,omitempty"`
-	AutoRefill    bool              `json:"auto_refill,omitempty"`
-	CleanupOnExit bool              `json:"cleanup_on_exit,omitempty"`
-	Aliases       map[string]string `json:"aliases,omitempty"`
+	PoolSize        int                      `json:"pool_size,omitempty"`
+	PoolPrefix      string                   `json:"pool_prefix,omitempty"`
+	DefaultBranch   string                   `json:"default_branch,omitempty"`
+	Editor          string                   `json:"editor,omitempty"`
+	Editors         map[string]EditorProfile `json:"editors,omitempty"`
+	AutoRefill      *bool                    `json:"auto_refill,omitempty"`
+	CleanupOnExit   *bool                    `json:"cleanup_on_exit,omitempty"`
+	Aliases         map[string]string        `json:"aliases,omitempty"`
+	LockTimeout     string                   `json:"lock_timeout,omitempty"`
+	Warmup          []WarmupStep             `json:"warmup,omitempty"`
+	Hooks           map[string][]string      `json:"hooks,omitempty"`
+	HookTimeout     string                   `json:"hook_timeout,omitempty"`
+	Sync            []SyncRule               `json:"sync,omitempty"`
+	RefreshOnRefill *bool                    `json:"refresh_on_refill,omitempty"`
+	RemoteMaxAge    string                   `json:"remote_max_age,omitempty"`
+	Offline         *bool                    `json:"offline,omitempty"`
+	WorktreePath    string                   `json:"worktree_path,omitempty"`
+	WorktreeRoot    string                   `json:"worktree_root,omitempty"`
+	Preserve        []string                 `json:"preserve,omitempty"`
 }
 
 func DefaultConfig() *Config {
@@ -32,9 +62,11 @@ func DefaultConfig() *Config {
 		PoolPrefix:    "pool-",
 		DefaultBranch: "main",
 		Editor:        "code",
-		AutoRefill:    true,
-		CleanupOnExit: false,
+		AutoRefill:    boolPtr(true),
 		Aliases:       make(map[string]string),
+		LockTimeout:   "10s",
+		RemoteMaxAge:  "5m",
+		WorktreePath:  DefaultWorktreePath,
 	}
 }
 
@@ -65,6 +97,27 @@ func Load() (*Config, error) {
 	return config, nil
 }
 
+// ReadFile reads a single config file as written, without defaults or the
+// other config files merged in, so that it can be edited and saved back. A
+// missing file yields an empty config.
+func ReadFile(path string) (*Config, error) {
+	config := &Config{}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read config file")
+	}
+
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, errors.Wrap(err, "invalid JSON in config file")
+	}
+
+	return config, nil
+}
+
 func (c *Config) Save(path string) error {
 	data, err := json.MarshalIndent(c, "", "  ")
 	if err != nil {
@@ -99,9 +152,152 @@ func (c *Config) Validate() error {
 		return errors.NewValidationError("editor", "", "cannot be empty")
 	}
 
+	if err := c.validateEditors(); err != nil {
+		return err
+	}
+
+	if c.LockTimeout != "" {
+		timeout, err := time.ParseDuration(c.LockTimeout)
+		if err != nil {
+			return errors.NewValidationError("lock_timeout", c.LockTimeout, "must be a duration such as 10s or 1m")
+		}
+		if timeout < 0 {
+			return errors.NewValidationError("lock_timeout", c.LockTimeout, "cannot be negative")
+		}
+	}
+
+	if c.RemoteMaxAge != "" {
+		if _, err := time.ParseDuration(c.RemoteMaxAge); err != nil {
+			return errors.NewValidationError("remote_max_age", c.RemoteMaxAge, "must be a duration such as 5m or 1h")
+		}
+	}
+
+	if err := c.validateWorktreePath(); err != nil {
+		return err
+	}
+
+	if c.HookTimeout != "" {
+		if _, err := time.ParseDuration(c.HookTimeout); err != nil {
+			return errors.NewValidationError("hook_timeout", c.HookTimeout, "must be a duration such as 30s or 2m")
+		}
+	}
+
+	for event := range c.Hooks {
+		if !hooks.IsEvent(event) {
+			return errors.NewValidationError("hooks", event, "unknown hook, expected one of pre-claim, post-claim, pre-remove, post-refill")
+		}
+	}
+
+	for i, rule := range c.Sync {
+		field := fmt.Sprintf("sync[%d]", i)
+
+		if rule.Pattern == "" {
+			return errors.NewValidationError(field, "", "pattern cannot be empty")
+		}
+
+		switch rule.Strategy {
+		case "", "copy", "symlink", "reflink":
+		default:
+			return errors.NewValidationError(field, rule.Strategy, "strategy must be copy, symlink or reflink")
+		}
+
+		switch rule.Overwrite {
+		case "", "never", "always", "newer":
+		default:
+			return errors.NewValidationError(field, rule.Overwrite, "overwrite must be never, always or newer")
+		}
+	}
+
+	for name, expansion := range c.Aliases {
+		if err := ValidateAlias(name, expansion); err != nil {
+			return err
+		}
+	}
+
+	for i, pattern := range c.Preserve {
+		if strings.TrimSpace(pattern) == "" {
+			return errors.NewValidationError(fmt.Sprintf("preserve[%d]", i), "", "pattern cannot be empty")
+		}
+	}
+
+	for i, step := range c.Warmup {
+		if step.When == "" || step.Run == "" {
+			return errors.NewValidationError(fmt.Sprintf("warmup[%d]", i), "", "must set both when and run")
+		}
+	}
+
+	return nil
+}
+
+// ValidateAlias checks that name can be typed as a command word and that it
+// expands to something.
+func ValidateAlias(name, expansion string) error {
+	if name == "" || strings.HasPrefix(name, "-") || strings.ContainsAny(name, " \t") {
+		return errors.NewValidationError("aliases", name, "alias names must be a single word not starting with -")
+	}
+
+	if strings.TrimSpace(expansion) == "" {
+		return errors.NewValidationError("aliases", name, "alias cannot be empty")
+	}
+
 	return nil
 }
 
+// GetLockTimeout returns how long to wait for another pool process to
+// release the pool lock. It falls back to 10 seconds when unset.
+func (c *Config) GetLockTimeout() time.Duration {
+	timeout, err := time.ParseDuration(c.LockTimeout)
+	if err != nil {
+		return 10 * time.Second
+	}
+	return timeout
+}
+
+// GetRemoteMaxAge returns how old the remote-tracking refs may be before
+// commands that look up remote branches fetch again. It falls back to 5
+// minutes when unset.
+func (c *Config) GetRemoteMaxAge() time.Duration {
+	maxAge, err := time.ParseDuration(c.RemoteMaxAge)
+	if err != nil {
+		return 5 * time.Minute
+	}
+	return maxAge
+}
+
+// GetHookTimeout returns how long a single hook may run. It falls back to 60
+// seconds when unset.
+func (c *Config) GetHookTimeout() time.Duration {
+	timeout, err := time.ParseDuration(c.HookTimeout)
+	if err != nil {
+		return 60 * time.Second
+	}
+	return timeout
+}
+
+// GetAutoRefill reports whether claiming a worktree starts a background
+// refill. The boolean settings are pointers so that a repo-local false can
+// override a global true, and a file that omits them overrides nothing.
+func (c *Config) GetAutoRefill() bool {
+	return c.AutoRefill != nil && *c.AutoRefill
+}
+
+// GetCleanupOnExit reports whether worktrees are returned to the pool when
+// the editor closes.
+func (c *Config) GetCleanupOnExit() bool {
+	return c.CleanupOnExit != nil && *c.CleanupOnExit
+}
+
+// GetOffline reports whether fetching is turned off.
+func (c *Config) GetOffline() bool {
+	return c.Offline != nil && *c.Offline
+}
+
+// GetRefreshOnRefill reports whether available worktrees are refreshed after
+// each refill.
+func (c *Config) GetRefreshOnRefill() bool {
+	return c.RefreshOnRefill != nil && *c.RefreshOnRefill
+}
+
 func (c *Config) loadFromEnv() error {
 	if poolSize := os.Getenv("WORKTREE_POOL_SIZE"); poolSize != "" {
 		var size int
@@ -118,6 +314,10 @@ func (c *Config) loadFromEnv() error {
 		c.Editor = editor
 	}
 
+	if timeout := os.Getenv("POOL_LOCK_TIMEOUT"); timeout != "" {
+		c.LockTimeout = timeout
+	}
+
 	return nil
 }
 
@@ -154,14 +354,72 @@ func (c *Config) merge(other *Config) {
 		c.Editor = other.Editor
 	}
 
-	if other.AutoRefill != c.AutoRefill {
+	if other.Editors != nil {
+		if c.Editors == nil {
+			c.Editors = make(map[string]EditorProfile)
+		}
+		for name, profile := range other.Editors {
+			c.Editors[name] = profile
+		}
+	}
+
+	if other.AutoRefill != nil {
 		c.AutoRefill = other.AutoRefill
 	}
 
-	if other.CleanupOnExit != c.CleanupOnExit {
+	if other.CleanupOnExit != nil {
 		c.CleanupOnExit = other.CleanupOnExit
 	}
 
+	if other.RefreshOnRefill != nil {
+		c.RefreshOnRefill = other.RefreshOnRefill
+	}
+
+	if other.Preserve != nil {
+		c.Preserve = other.Preserve
+	}
+
+	if other.WorktreePath != "" {
+		c.WorktreePath = other.WorktreePath
+	}
+
+	if other.WorktreeRoot != "" {
+		c.WorktreeRoot = other.WorktreeRoot
+	}
+
+	if other.Offline != nil {
+		c.Offline = other.Offline
+	}
+
+	if other.RemoteMaxAge != "" {
+		c.RemoteMaxAge = other.RemoteMaxAge
+	}
+
+	if other.LockTimeout != "" {
+		c.LockTimeout = other.LockTimeout
+	}
+
+	if other.HookTimeout != "" {
+		c.HookTimeout = other.HookTimeout
+	}
+
+	if other.Hooks != nil {
+		if c.Hooks == nil {
+			c.Hooks = make(map[string][]string)
+		}
+		for event, commands := range other.Hooks {
+			c.Hooks[event] = commands
+		}
+	}
+
+	if other.Sync != nil {
+		c.Sync = other.Sync
+	}
+
+	if other.Warmup != nil {
+		c.Warmup = other.Warmup
+	}
+
 	if other.Aliases != nil {
 		if c.Aliases == nil {
 			c.Aliases = make(map[string]string)
@@ -172,6 +430,10 @@ func (c *Config) merge(other *Config) {
 	}
 }
 
+func boolPtr(v bool) *bool {
+	return &v
+}
+
 func findLocalConfig() (string, error) {
 	if _, err := os.Stat(ConfigFileName); err == nil {
 		return ConfigFileName, nil
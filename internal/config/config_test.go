@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -22,9 +24,17 @@ func TestDefaultConfig(t *testing.T) {
 		t.Errorf("Expected default editor 'code', got %s", cfg.Editor)
 	}
 
-	if !cfg.AutoRefill {
+	if !cfg.GetAutoRefill() {
 		t.Error("Expected auto refill to be true by default")
 	}
+
+	if cfg.GetLockTimeout() != 10*time.Second {
+		t.Errorf("Expected default lock timeout 10s, got %s", cfg.GetLockTimeout())
+	}
+
+	if cfg.GetRemoteMaxAge() != 5*time.Minute {
+		t.Errorf("Expected default remote max age 5m, got %s", cfg.GetRemoteMaxAge())
+	}
 }
 
 func TestConfigValidation(t *testing.T) {
@@ -68,6 +78,72 @@ func TestConfigValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Invalid lock timeout",
+			config: &Config{
+				PoolSize:      5,
+				PoolPrefix:    "pool-",
+				DefaultBranch: "main",
+				Editor:        "code",
+				LockTimeout:   "soon",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Invalid remote max age",
+			config: &Config{
+				PoolSize:      5,
+				PoolPrefix:    "pool-",
+				DefaultBranch: "main",
+				Editor:        "code",
+				RemoteMaxAge:  "daily",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Unknown worktree path field",
+			config: &Config{
+				PoolSize:      5,
+				PoolPrefix:    "pool-",
+				DefaultBranch: "main",
+				Editor:        "code",
+				WorktreePath:  "{{.Root}}/{{.Name}}",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Warmup step without command",
+			config: &Config{
+				PoolSize:      5,
+				PoolPrefix:    "pool-",
+				DefaultBranch: "main",
+				Editor:        "code",
+				Warmup:        []WarmupStep{{When: "package-lock.json"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Unknown hook",
+			config: &Config{
+				PoolSize:      5,
+				PoolPrefix:    "pool-",
+				DefaultBranch: "main",
+				Editor:        "code",
+				Hooks:         map[string][]string{"post-checkout": {"true"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Unknown sync strategy",
+			config: &Config{
+				PoolSize:      5,
+				PoolPrefix:    "pool-",
+				DefaultBranch: "main",
+				Editor:        "code",
+				Sync:          []SyncRule{{Pattern: ".env", Strategy: "hardlink"}},
+			},
+			wantErr: true,
+		},
 		{
 			name: "Empty editor",
 			config: &Config{
@@ -104,8 +180,8 @@ func TestConfigSaveLoad(t *testing.T) {
 		PoolPrefix:    "wp-",
 		DefaultBranch: "develop",
 		Editor:        "vim",
-		AutoRefill:    false,
-		CleanupOnExit: true,
+		AutoRefill:    boolPtr(false),
+		CleanupOnExit: boolPtr(true),
 		Aliases: map[string]string{
 			"feat": "feature",
 			"fix":  "bugfix",
@@ -142,8 +218,8 @@ func TestConfigSaveLoad(t *testing.T) {
 		t.Errorf("Expected editor %s, got %s", cfg.Editor, loaded.Editor)
 	}
 
-	if loaded.AutoRefill != cfg.AutoRefill {
-		t.Errorf("Expected auto refill %v, got %v", cfg.AutoRefill, loaded.AutoRefill)
+	if loaded.AutoRefill == nil || *loaded.AutoRefill != *cfg.AutoRefill {
+		t.Errorf("Expected auto refill %v to be saved, got %v", *cfg.AutoRefill, loaded.AutoRefill)
 	}
 
 	if len(loaded.Aliases) != len(cfg.Aliases) {
@@ -157,14 +233,15 @@ func TestConfigMerge(t *testing.T) {
 		PoolPrefix:    "pool-",
 		DefaultBranch: "main",
 		Editor:        "code",
-		AutoRefill:    true,
+		AutoRefill:    boolPtr(true),
+		CleanupOnExit: boolPtr(true),
 		Aliases:       map[string]string{"f": "feature"},
 	}
 
 	override := &Config{
 		PoolSize:   10,
 		Editor:     "nvim",
-		AutoRefill: false,
+		AutoRefill: boolPtr(false),
 		Aliases:    map[string]string{"b": "bugfix"},
 	}
 
@@ -179,7 +256,7 @@ func TestConfigMerge(t *testing.T) {
 		t.Errorf("Expected editor nvim, got %s", base.Editor)
 	}
 
-	if base.AutoRefill != false {
+	if base.GetAutoRefill() {
 		t.Error("Expected auto refill to be false")
 	}
 
@@ -188,8 +265,142 @@ func TestConfigMerge(t *testing.T) {
 		t.Errorf("Expected pool prefix to remain 'pool-', got %s", base.PoolPrefix)
 	}
 
+	if !base.GetCleanupOnExit() {
+		t.Error("Expected cleanup on exit to remain true")
+	}
+
 	// Check aliases were merged
 	if len(base.Aliases) != 2 {
 		t.Errorf("Expected 2 aliases after merge, got %d", len(base.Aliases))
 	}
 }
+
+func TestConfigMergeFalseOverridesTrue(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	globalPath := filepath.Join(tmpDir, "global.json")
+	localPath := filepath.Join(tmpDir, "local.json")
+	if err := os.WriteFile(globalPath, []byte(`{"offline": true, "refresh_on_refill": true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(localPath, []byte(`{"offline": false}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultConfig()
+	if err := config.loadFromFile(globalPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.loadFromFile(localPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.GetOffline() {
+		t.Error("Expected local offline false to override global true")
+	}
+	if !config.GetRefreshOnRefill() {
+		t.Error("Expected refresh on refill to stay set when the local config omits it")
+	}
+}
+
+func TestWorktreePathFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		root     string
+		branch   string
+		want     string
+	}{
+		{
+			name:   "Default",
+			branch: "feat/login",
+			want:   "/src/app/feat-login",
+		},
+		{
+			name:     "Sibling directory",
+			template: "{{.Root}}/../{{.Repo}}-worktrees/{{.Branch | slug}}",
+			branch:   "feat/login",
+			want:     "/src/app-worktrees/feat-login",
+		},
+		{
+			name:     "Nested branch directories",
+			template: "{{.Root}}/{{.Branch}}",
+			root:     "/worktrees",
+			branch:   "feat/login",
+			want:     "/worktrees/feat/login",
+		},
+		{
+			name:     "Relative root",
+			template: "{{.Root}}/{{.Branch | slug}}",
+			root:     "../trees",
+			branch:   "fix",
+			want:     "/src/trees/fix",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{WorktreePath: tt.template, WorktreeRoot: tt.root}
+
+			got, err := cfg.WorktreePathFor("/src/app", tt.branch)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != filepath.FromSlash(tt.want) {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEditorProfiles(t *testing.T) {
+	config := DefaultConfig()
+	config.Editors = map[string]EditorProfile{
+		"work": {Command: "code --profile {repo} {path}", Env: map[string]string{"POOL_BRANCH": "{branch}"}},
+	}
+
+	vars := EditorVars{Path: "/src/my repo/feat-a", Branch: "feat/a", Repo: "my repo"}
+
+	profile, ok := config.EditorProfile("work")
+	if !ok {
+		t.Fatal("Expected configured profile to be found")
+	}
+
+	args := profile.Args(vars, false)
+	expected := []string{"code", "--profile", "my repo", "/src/my repo/feat-a"}
+	if strings.Join(args, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected args %q, got %q", expected, args)
+	}
+
+	if env := profile.Environ(vars); len(env) != 1 || env[0] != "POOL_BRANCH=feat/a" {
+		t.Errorf("Expected branch in environment, got %v", env)
+	}
+
+	code := config.DefaultEditorProfile()
+	if args := code.Args(vars, true); strings.Join(args, " ") != "code --wait /src/my repo/feat-a" {
+		t.Errorf("Expected wait flag after the command name, got %q", args)
+	}
+
+	if !code.CanWait() || profile.CanWait() {
+		t.Error("Expected only profiles with a wait flag or in the foreground to wait")
+	}
+
+	config.Editor = "hx"
+	if args := config.DefaultEditorProfile().Args(vars, false); len(args) != 2 || args[1] != vars.Path {
+		t.Errorf("Expected unknown editor to be run with the path, got %q", args)
+	}
+
+	if config.DefaultEditorProfile().CanWait() {
+		t.Error("Expected unknown editor not to wait")
+	}
+
+	if _, ok := config.EditorProfile("missing"); ok {
+		t.Error("Expected unknown profile not to be found")
+	}
+
+	config.Editors["empty"] = EditorProfile{}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected profile without a command to be invalid")
+	}
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mskelton/pool/internal/errors"
+)
+
+// EditorProfile describes how to launch an editor, e.g.
+// {"command": "code --new-window {path}"}.
+type EditorProfile struct {
+	// Command is split on whitespace, and {path}, {branch} and {repo} are
+	// replaced in each argument. It runs in the worktree.
+	Command string `json:"command"`
+	// Foreground runs the editor attached to the terminal and waits for it to
+	// exit, for terminal editors such as vim.
+	Foreground bool `json:"foreground,omitempty"`
+	// WaitFlag makes a GUI editor block until its window is closed, which
+	// --ephemeral needs to know when the worktree is no longer used.
+	WaitFlag string `json:"wait_flag,omitempty"`
+	// Env is added to the editor's environment, with the same placeholders.
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// EditorVars are the values of the placeholders in an editor profile.
+type EditorVars struct {
+	Path   string
+	Branch string
+	// Repo is the repository directory name.
+	Repo string
+}
+
+var builtinEditors = map[string]EditorProfile{
+	"code":  {Command: "code {path}", WaitFlag: "--wait"},
+	"subl":  {Command: "subl {path}", WaitFlag: "--wait"},
+	"atom":  {Command: "atom {path}", WaitFlag: "--wait"},
+	"vim":   {Command: "vim", Foreground: true},
+	"nvim":  {Command: "nvim", Foreground: true},
+	"emacs": {Command: "emacs {path}"},
+}
+
+// EditorProfile returns the profile called name from the config, or the
+// built-in profile of that name.
+func (c *Config) EditorProfile(name string) (EditorProfile, bool) {
+	if profile, ok := c.Editors[name]; ok {
+		return profile, true
+	}
+
+	profile, ok := builtinEditors[name]
+	return profile, ok
+}
+
+// DefaultEditorProfile returns the profile named by editor. An editor that
+// names no profile is run as a command, with the worktree path appended
+// unless it uses placeholders itself.
+func (c *Config) DefaultEditorProfile() EditorProfile {
+	if profile, ok := c.EditorProfile(c.Editor); ok {
+		return profile
+	}
+
+	if strings.Contains(c.Editor, "{") {
+		return EditorProfile{Command: c.Editor}
+	}
+	return EditorProfile{Command: c.Editor + " {path}"}
+}
+
+// EditorNames lists the configured and built-in editor profiles.
+func (c *Config) EditorNames() []string {
+	var names []string
+	for name := range builtinEditors {
+		names = append(names, name)
+	}
+	for name := range c.Editors {
+		if _, ok := builtinEditors[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// CanWait reports whether running the profile blocks until the editor is
+// closed, either because it runs in the foreground or with its wait flag.
+func (p EditorProfile) CanWait() bool {
+	return p.Foreground || p.WaitFlag != ""
+}
+
+// Args returns the command line of the profile for vars. With wait set, the
+// wait flag is added after the command name.
+func (p EditorProfile) Args(vars EditorVars, wait bool) []string {
+	replacer := vars.replacer()
+
+	var args []string
+	for i, field := range strings.Fields(p.Command) {
+		args = append(args, replacer.Replace(field))
+		if i == 0 && wait && p.WaitFlag != "" {
+			args = append(args, p.WaitFlag)
+		}
+	}
+
+	return args
+}
+
+// Environ returns the profile's environment additions for vars, as
+// KEY=value pairs.
+func (p EditorProfile) Environ(vars EditorVars) []string {
+	replacer := vars.replacer()
+
+	var env []string
+	for key, value := range p.Env {
+		env = append(env, key+"="+replacer.Replace(value))
+	}
+
+	return env
+}
+
+func (v EditorVars) replacer() *strings.Replacer {
+	return strings.NewReplacer("{path}", v.Path, "{branch}", v.Branch, "{repo}", v.Repo)
+}
+
+func (c *Config) validateEditors() error {
+	for name, profile := range c.Editors {
+		if strings.TrimSpace(profile.Command) == "" {
+			return errors.NewValidationError(fmt.Sprintf("editors.%s", name), "", "command cannot be empty")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/mskelton/pool/internal/errors"
+)
+
+// DefaultWorktreePath puts each worktree in the repository root, named after
+// its branch with slashes replaced by dashes.
+const DefaultWorktreePath = "{{.Root}}/{{.Branch | slug}}"
+
+// PathData is the data available to the worktree_path template.
+type PathData struct {
+	// Root is worktree_root, or the repository root when it is unset.
+	Root   string
+	Repo   string
+	Branch string
+}
+
+var templateFuncs = template.FuncMap{
+	"slug": Slug,
+}
+
+// Slug turns a branch name into a single path component.
+func Slug(branch string) string {
+	return strings.ReplaceAll(branch, "/", "-")
+}
+
+// WorktreePathFor renders the worktree_path template for branch in the
+// repository at repoRoot. Relative results are resolved against repoRoot.
+func (c *Config) WorktreePathFor(repoRoot, branch string) (string, error) {
+	tmpl, err := c.worktreePathTemplate()
+	if err != nil {
+		return "", err
+	}
+
+	data := PathData{
+		Root:   c.worktreeRoot(repoRoot),
+		Repo:   filepath.Base(repoRoot),
+		Branch: branch,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", errors.Wrap(err, "failed to render worktree_path")
+	}
+
+	path := strings.TrimSpace(buf.String())
+	if path == "" {
+		return "", errors.NewValidationError("worktree_path", c.WorktreePath, "renders an empty path")
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(repoRoot, path)
+	}
+
+	return filepath.Clean(path), nil
+}
+
+func (c *Config) worktreePathTemplate() (*template.Template, error) {
+	text := c.WorktreePath
+	if text == "" {
+		text = DefaultWorktreePath
+	}
+
+	tmpl, err := template.New("worktree_path").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, errors.NewValidationError("worktree_path", text, err.Error())
+	}
+
+	return tmpl, nil
+}
+
+func (c *Config) worktreeRoot(repoRoot string) string {
+	root := c.WorktreeRoot
+	if root == "" {
+		return repoRoot
+	}
+
+	if rest, ok := strings.CutPrefix(root, "~/"); ok {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			root = filepath.Join(homeDir, rest)
+		}
+	}
+
+	if !filepath.IsAbs(root) {
+		root = filepath.Join(repoRoot, root)
+	}
+
+	return root
+}
+
+func (c *Config) validateWorktreePath() error {
+	tmpl, err := c.worktreePathTemplate()
+	if err != nil {
+		return err
+	}
+
+	sample := PathData{Root: "/root", Repo: "repo", Branch: "feature/name"}
+	if err := tmpl.Execute(&bytes.Buffer{}, sample); err != nil {
+		return errors.NewValidationError("worktree_path", c.WorktreePath, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,299 @@
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mskelton/pool/internal/git"
+	"github.com/mskelton/pool/internal/pool"
+)
+
+var Checks = []Check{
+	{
+		Name:        "missing-directories",
+		Description: "Pool entries whose directory no longer exists",
+		Run:         checkMissingDirectories,
+	},
+	{
+		Name:        "unregistered-entries",
+		Description: "Pool entries that git does not know as worktrees",
+		Run:         checkUnregisteredEntries,
+	},
+	{
+		Name:        "untracked-directories",
+		Description: "Directories in the pool that are not pool entries",
+		Run:         checkUntrackedDirectories,
+	},
+	{
+		Name:        "prunable-worktrees",
+		Description: "Worktrees git reports as prunable",
+		Run:         checkPrunableWorktrees,
+	},
+	{
+		Name:        "wrong-branch",
+		Description: "Available pool worktrees that have a branch checked out",
+		Run:         checkWrongBranch,
+	},
+	{
+		Name:        "fetch-refspec",
+		Description: "Bare repositories missing the remote.origin.fetch refspec",
+		Run:         checkFetchRefspec,
+	},
+	{
+		Name:        "pool-capacity",
+		Description: "Fewer pool worktrees than the configured pool size",
+		Run:         checkPoolCapacity,
+	},
+}
+
+func checkMissingDirectories(ctx *Context) []Finding {
+	var findings []Finding
+
+	for _, name := range sortedNames(ctx.Manager) {
+		// A live claim may be moving the directory out of the pool.
+		if ctx.Manager.Status.Worktrees[name] == pool.StatusCreating || ctx.Manager.ClaimActive(name) {
+			continue
+		}
+
+		if _, err := os.Stat(ctx.Manager.WorktreePath(name)); !os.IsNotExist(err) {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Status:  StatusFail,
+			Message: fmt.Sprintf("%s is tracked but its directory is missing", name),
+			Fix: &Fix{
+				Description: fmt.Sprintf("Remove %s from the pool status", name),
+				Apply: func() error {
+					return ctx.Manager.Remove(name)
+				},
+			},
+		})
+	}
+
+	return findings
+}
+
+func checkUnregisteredEntries(ctx *Context) []Finding {
+	var findings []Finding
+	registered := ctx.poolWorktrees()
+
+	for _, name := range sortedNames(ctx.Manager) {
+		path := ctx.Manager.WorktreePath(name)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+
+		if _, ok := registered[name]; ok {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Status:  StatusFail,
+			Message: fmt.Sprintf("%s exists but is not a registered git worktree", name),
+			Fix: &Fix{
+				Description: fmt.Sprintf("Delete %s and remove it from the pool status", path),
+				Destructive: true,
+				Apply: func() error {
+					if err := os.RemoveAll(path); err != nil {
+						return err
+					}
+					return ctx.Manager.Remove(name)
+				},
+			},
+		})
+	}
+
+	return findings
+}
+
+func checkUntrackedDirectories(ctx *Context) []Finding {
+	entries, err := os.ReadDir(ctx.Manager.Path())
+	if err != nil {
+		return nil
+	}
+
+	var findings []Finding
+	registered := ctx.poolWorktrees()
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() {
+			continue
+		}
+
+		if _, ok := ctx.Manager.Status.Worktrees[name]; ok {
+			continue
+		}
+
+		path := ctx.Manager.WorktreePath(name)
+		wt, ok := registered[name]
+
+		switch {
+		case !ok:
+			findings = append(findings, Finding{
+				Status:  StatusWarn,
+				Message: fmt.Sprintf("%s is not a pool entry or a git worktree", name),
+				Fix: &Fix{
+					Description: fmt.Sprintf("Delete %s", path),
+					Destructive: true,
+					Apply: func() error {
+						return os.RemoveAll(path)
+					},
+				},
+			})
+
+		case wt.Detached && !git.IsDirty(path):
+			findings = append(findings, Finding{
+				Status:  StatusWarn,
+				Message: fmt.Sprintf("%s is a clean worktree but not a pool entry", name),
+				Fix: &Fix{
+					Description: fmt.Sprintf("Add %s to the pool as available", name),
+					Apply: func() error {
+						return ctx.Manager.MarkAvailable(name)
+					},
+				},
+			})
+
+		default:
+			findings = append(findings, Finding{
+				Status:  StatusWarn,
+				Message: fmt.Sprintf("%s is a worktree with a branch or changes but not a pool entry", name),
+				Fix: &Fix{
+					Description: fmt.Sprintf("Force remove worktree %s", path),
+					Destructive: true,
+					Apply: func() error {
+						return ctx.Repo.ForceRemoveWorktree(path)
+					},
+				},
+			})
+		}
+	}
+
+	return findings
+}
+
+func checkPrunableWorktrees(ctx *Context) []Finding {
+	var paths []string
+	for _, wt := range ctx.Worktrees {
+		if wt.Prunable {
+			paths = append(paths, wt.Path)
+		}
+	}
+
+	if len(paths) == 0 {
+		return nil
+	}
+
+	return []Finding{{
+		Status:  StatusWarn,
+		Message: fmt.Sprintf("%d prunable worktrees: %s", len(paths), strings.Join(paths, ", ")),
+		Fix: &Fix{
+			Description: "Run git worktree prune",
+			Apply:       ctx.Repo.PruneWorktrees,
+		},
+	}}
+}
+
+func checkWrongBranch(ctx *Context) []Finding {
+	var findings []Finding
+	registered := ctx.poolWorktrees()
+
+	for _, name := range sortedNames(ctx.Manager) {
+		wt, ok := registered[name]
+		if !ok || wt.Branch == "" || ctx.Manager.Status.Worktrees[name] != pool.StatusAvailable {
+			continue
+		}
+
+		path := wt.Path
+		findings = append(findings, Finding{
+			Status:  StatusFail,
+			Message: fmt.Sprintf("%s is available but has branch %s checked out", name, wt.Branch),
+			Fix: &Fix{
+				Description: fmt.Sprintf("Detach HEAD in %s", name),
+				Apply: func() error {
+					return git.RunInDir(path, "checkout", "--detach")
+				},
+			},
+		})
+	}
+
+	return findings
+}
+
+func checkFetchRefspec(ctx *Context) []Finding {
+	if !ctx.Repo.IsBare || !ctx.Repo.HasRemote("origin") {
+		return nil
+	}
+
+	if refspec, err := ctx.Repo.GetConfig("remote.origin.fetch"); err == nil && refspec != "" {
+		return nil
+	}
+
+	return []Finding{{
+		Status:  StatusFail,
+		Message: "remote.origin.fetch is not set, so remote branches are never fetched",
+		Fix: &Fix{
+			Description: fmt.Sprintf("Set remote.origin.fetch to %s", git.OriginFetchRefspec),
+			Apply: func() error {
+				return ctx.Repo.SetConfig("remote.origin.fetch", git.OriginFetchRefspec)
+			},
+		},
+	}}
+}
+
+// checkPoolCapacity compares the number of pool entries with the pool size,
+// which is what Refill fills up to. Entries in use count, as they are
+// replaced when they are released or moved out of the pool.
+func checkPoolCapacity(ctx *Context) []Finding {
+	total, available := ctx.Manager.GetStatus()
+	if total >= ctx.PoolSize {
+		return nil
+	}
+
+	return []Finding{{
+		Status:  StatusWarn,
+		Message: fmt.Sprintf("%d of %d pool worktrees exist, %d available", total, ctx.PoolSize, available),
+		Fix: &Fix{
+			Description: fmt.Sprintf("Refill the pool to %d worktrees", ctx.PoolSize),
+			Apply: func() error {
+				return ctx.Manager.Refill(ctx.PoolSize)
+			},
+		},
+	}}
+}
+
+// poolWorktrees returns the registered worktrees that live directly in the
+// pool directory, keyed by entry name.
+func (ctx *Context) poolWorktrees() map[string]git.Worktree {
+	poolPath := canonicalPath(ctx.Manager.Path())
+	worktrees := make(map[string]git.Worktree)
+
+	for _, wt := range ctx.Worktrees {
+		if canonicalPath(filepath.Dir(wt.Path)) == poolPath {
+			worktrees[filepath.Base(wt.Path)] = wt
+		}
+	}
+
+	return worktrees
+}
+
+func sortedNames(manager *pool.Manager) []string {
+	names := make([]string, 0, len(manager.Status.Worktrees))
+	for name := range manager.Status.Worktrees {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func canonicalPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,95 @@
+package doctor
+
+import (
+	"github.com/mskelton/pool/internal/git"
+	"github.com/mskelton/pool/internal/pool"
+)
+
+type Status int
+
+const (
+	StatusPass Status = iota
+	StatusWarn
+	StatusFail
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusWarn:
+		return "warn"
+	case StatusFail:
+		return "fail"
+	default:
+		return "pass"
+	}
+}
+
+// Context is the state shared by all checks in a run.
+type Context struct {
+	Repo      *git.Repository
+	Manager   *pool.Manager
+	PoolSize  int
+	Worktrees []git.Worktree
+}
+
+func NewContext(repo *git.Repository, manager *pool.Manager, poolSize int) (*Context, error) {
+	worktrees, err := repo.ListWorktrees()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Context{
+		Repo:      repo,
+		Manager:   manager,
+		PoolSize:  poolSize,
+		Worktrees: worktrees,
+	}, nil
+}
+
+// Fix is a repair for a single finding. Destructive fixes may delete files
+// or discard changes and must be confirmed before they are applied.
+type Fix struct {
+	Description string
+	Destructive bool
+	Apply       func() error
+}
+
+type Finding struct {
+	Status  Status
+	Message string
+	Fix     *Fix
+}
+
+type Check struct {
+	Name        string
+	Description string
+	Run         func(ctx *Context) []Finding
+}
+
+// Result holds the findings of a single check. A check without findings
+// passed.
+type Result struct {
+	Check    Check
+	Findings []Finding
+}
+
+func (r Result) Status() Status {
+	status := StatusPass
+	for _, finding := range r.Findings {
+		if finding.Status > status {
+			status = finding.Status
+		}
+	}
+	return status
+}
+
+func Run(ctx *Context, checks []Check) []Result {
+	results := make([]Result, 0, len(checks))
+	for _, check := range checks {
+		results = append(results, Result{
+			Check:    check,
+			Findings: check.Run(ctx),
+		})
+	}
+	return results
+}
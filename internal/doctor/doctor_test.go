@@ -0,0 +1,189 @@
+package doctor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/mskelton/pool/internal/git"
+	"github.com/mskelton/pool/internal/pool"
+)
+
+func TestDoctor(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "doctor-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := initTestRepo(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := git.NewRepository(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := pool.NewManager(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := manager.Initialize(3); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.RemoveAll(manager.WorktreePath("pool-1")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := git.RunInDir(manager.WorktreePath("pool-2"), "checkout", "-b", "stray"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Mkdir(filepath.Join(manager.Path(), "junk"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := NewContext(repo, manager, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statuses := make(map[string]Status)
+	for _, result := range Run(ctx, Checks) {
+		statuses[result.Check.Name] = result.Status()
+
+		for _, finding := range result.Findings {
+			if finding.Fix == nil {
+				t.Errorf("Expected %s finding to have a fix", result.Check.Name)
+				continue
+			}
+			if err := finding.Fix.Apply(); err != nil {
+				t.Errorf("Failed to apply fix for %s: %v", result.Check.Name, err)
+			}
+		}
+	}
+
+	expected := map[string]Status{
+		"missing-directories":   StatusFail,
+		"unregistered-entries":  StatusPass,
+		"untracked-directories": StatusWarn,
+		"prunable-worktrees":    StatusWarn,
+		"wrong-branch":          StatusFail,
+		"fetch-refspec":         StatusPass,
+		"pool-capacity":         StatusPass,
+	}
+
+	for name, status := range expected {
+		if statuses[name] != status {
+			t.Errorf("Expected %s to be %s, got %s", name, status, statuses[name])
+		}
+	}
+
+	manager, err = pool.NewManager(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err = NewContext(repo, manager, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, result := range Run(ctx, Checks) {
+		if result.Status() != StatusPass {
+			t.Errorf("Expected %s to pass after fixes, got %s: %v", result.Check.Name, result.Status(), result.Findings)
+		}
+	}
+}
+
+func TestMissingDirectoryOfLiveClaim(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "doctor-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := initTestRepo(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := git.NewRepository(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := pool.NewManager(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := manager.Initialize(1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Claimed by this process and moved out, as during pool <branch>.
+	path, _, err := manager.Claim("feature")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.MoveWorktree(path, filepath.Join(tmpDir, "feature")); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := NewContext(repo, manager, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if findings := checkMissingDirectories(ctx); len(findings) != 0 {
+		t.Errorf("Expected entry of a live claim to be skipped, got %v", findings)
+	}
+}
+
+func initTestRepo(dir string) error {
+	cmd := exec.Command("git", "init")
+	cmd.Dir = dir
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+
+	cmd = exec.Command("git", "config", "user.email", "test@example.com")
+	cmd.Dir = dir
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+
+	cmd = exec.Command("git", "config", "user.name", "Test User")
+	cmd.Dir = dir
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+
+	testFile := filepath.Join(dir, "README.md")
+	if err := os.WriteFile(testFile, []byte("# Test Repo"), 0644); err != nil {
+		return err
+	}
+
+	cmd = exec.Command("git", "add", ".")
+	cmd.Dir = dir
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+
+	cmd = exec.Command("git", "commit", "-m", "Initial commit")
+	cmd.Dir = dir
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+
+	cmd = exec.Command("git", "branch", "-M", "main")
+	cmd.Dir = dir
+	cmd.Run()
+
+	return nil
+}
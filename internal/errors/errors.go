@@ -14,6 +14,10 @@ var (
 	ErrPoolNotFound    = errors.New("worktree pool not found")
 	ErrWorktreeExists  = errors.New("worktree already exists")
 	ErrBranchNotFound  = errors.New("branch not found")
+	ErrPoolLocked      = errors.New("worktree pool is locked")
+	ErrOffline         = errors.New("network access is disabled in offline mode")
+	ErrNoRemote        = errors.New("repository has no origin remote")
+	ErrCrossDevice     = errors.New("cannot move a worktree to another filesystem")
 )
 
 type OperationError struct {
@@ -92,3 +96,11 @@ func Wrapf(err error, format string, args ...interface{}) error {
 	}
 	return fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), err)
 }
+
+func Is(err, target error) bool {
+	return errors.Is(err, target)
+}
+
+func As(err error, target interface{}) bool {
+	return errors.As(err, target)
+}
@@ -0,0 +1,211 @@
+package filesync
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/mskelton/pool/internal/config"
+	"github.com/mskelton/pool/internal/errors"
+)
+
+const (
+	StrategyCopy    = "copy"
+	StrategySymlink = "symlink"
+	StrategyReflink = "reflink"
+
+	OverwriteNever  = "never"
+	OverwriteAlways = "always"
+	OverwriteNewer  = "newer"
+)
+
+// Action is a single planned sync of a path matched by a rule. Actions with
+// a non-empty Skip are reported but not applied.
+type Action struct {
+	Path     string
+	Source   string
+	Dest     string
+	Strategy string
+	Replace  bool
+	Skip     string
+}
+
+func (a Action) String() string {
+	if a.Skip != "" {
+		return fmt.Sprintf("skip %s (%s)", a.Path, a.Skip)
+	}
+
+	verb := a.Strategy
+	if a.Replace {
+		verb += " (replace)"
+	}
+
+	if a.Strategy == StrategySymlink {
+		return fmt.Sprintf("%s %s -> %s", verb, a.Path, a.Source)
+	}
+	return fmt.Sprintf("%s %s", verb, a.Path)
+}
+
+// Plan matches each rule's pattern in src and decides what to do with the
+// corresponding path in dst. Patterns use filepath.Match syntax relative to
+// the worktree root.
+func Plan(rules []config.SyncRule, src, dst string) ([]Action, error) {
+	var actions []Action
+	seen := make(map[string]bool)
+
+	for _, rule := range rules {
+		matches, err := filepath.Glob(filepath.Join(src, rule.Pattern))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid sync pattern %q", rule.Pattern)
+		}
+		sort.Strings(matches)
+
+		for _, source := range matches {
+			rel, err := filepath.Rel(src, source)
+			if err != nil {
+				return nil, err
+			}
+
+			if seen[rel] {
+				continue
+			}
+			seen[rel] = true
+
+			actions = append(actions, plan(rule, rel, source, filepath.Join(dst, rel)))
+		}
+	}
+
+	return actions, nil
+}
+
+func plan(rule config.SyncRule, rel, source, dest string) Action {
+	action := Action{
+		Path:     rel,
+		Source:   source,
+		Dest:     dest,
+		Strategy: rule.Strategy,
+	}
+
+	if action.Strategy == "" {
+		action.Strategy = StrategyCopy
+	}
+
+	destInfo, err := os.Lstat(dest)
+	if os.IsNotExist(err) {
+		return action
+	}
+	if err != nil {
+		action.Skip = err.Error()
+		return action
+	}
+
+	switch rule.Overwrite {
+	case OverwriteAlways:
+		action.Replace = true
+	case OverwriteNewer:
+		sourceInfo, err := os.Stat(source)
+		if err == nil && sourceInfo.ModTime().After(destInfo.ModTime()) {
+			action.Replace = true
+		} else {
+			action.Skip = "destination is up to date"
+		}
+	default:
+		action.Skip = "already exists"
+	}
+
+	return action
+}
+
+// Apply performs the planned actions, returning the first error after
+// attempting all of them.
+func Apply(actions []Action) error {
+	var firstErr error
+
+	for _, action := range actions {
+		if action.Skip != "" {
+			continue
+		}
+
+		if err := apply(action); err != nil && firstErr == nil {
+			firstErr = errors.Wrapf(err, "failed to sync %s", action.Path)
+		}
+	}
+
+	return firstErr
+}
+
+func apply(action Action) error {
+	if action.Replace {
+		if err := os.RemoveAll(action.Dest); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(action.Dest), 0755); err != nil {
+		return err
+	}
+
+	switch action.Strategy {
+	case StrategySymlink:
+		return os.Symlink(action.Source, action.Dest)
+	case StrategyReflink:
+		return copyTree(action.Source, action.Dest, true)
+	default:
+		return copyTree(action.Source, action.Dest, false)
+	}
+}
+
+func copyTree(src, dst string, reflink bool) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case reflink:
+			if err := cloneFile(path, target, info.Mode().Perm()); err == nil {
+				return nil
+			}
+			os.Remove(target)
+			return copyFile(path, target, info.Mode().Perm())
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
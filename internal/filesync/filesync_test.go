@@ -0,0 +1,136 @@
+package filesync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mskelton/pool/internal/config"
+)
+
+func TestSync(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "filesync-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	dst := filepath.Join(tmpDir, "dst")
+
+	files := map[string]string{
+		".env":                  "SECRET=1",
+		".vscode/settings.json": "{}",
+		"certs/local.pem":       "cert",
+		"cache/a/b.bin":         "cache",
+		"existing.txt":          "new",
+	}
+
+	for path, content := range files {
+		writeFile(t, filepath.Join(src, path), content)
+	}
+	writeFile(t, filepath.Join(dst, "existing.txt"), "old")
+
+	rules := []config.SyncRule{
+		{Pattern: ".env"},
+		{Pattern: ".vscode/settings.json", Strategy: StrategySymlink},
+		{Pattern: "certs/*.pem", Strategy: StrategyReflink},
+		{Pattern: "cache"},
+		{Pattern: "existing.txt"},
+		{Pattern: "missing.txt"},
+	}
+
+	actions, err := Plan(rules, src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(actions) != 5 {
+		t.Fatalf("Expected 5 actions, got %d: %v", len(actions), actions)
+	}
+
+	if err := Apply(actions); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{".env", "certs/local.pem", "cache/a/b.bin"} {
+		data, err := os.ReadFile(filepath.Join(dst, path))
+		if err != nil {
+			t.Errorf("Expected %s to be copied: %v", path, err)
+			continue
+		}
+		if string(data) != files[path] {
+			t.Errorf("Expected %s to contain %q, got %q", path, files[path], data)
+		}
+	}
+
+	link, err := os.Readlink(filepath.Join(dst, ".vscode/settings.json"))
+	if err != nil {
+		t.Errorf("Expected settings.json to be a symlink: %v", err)
+	} else if link != filepath.Join(src, ".vscode/settings.json") {
+		t.Errorf("Expected symlink to point at the source, got %s", link)
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(dst, "existing.txt")); string(data) != "old" {
+		t.Errorf("Expected existing file to be kept, got %q", data)
+	}
+}
+
+func TestOverwritePolicies(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "filesync-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src")
+	dst := filepath.Join(tmpDir, "dst")
+
+	writeFile(t, filepath.Join(src, "file"), "source")
+	writeFile(t, filepath.Join(dst, "file"), "dest")
+
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dst, "file"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		overwrite string
+		replace   bool
+	}{
+		{overwrite: "", replace: false},
+		{overwrite: OverwriteNever, replace: false},
+		{overwrite: OverwriteAlways, replace: true},
+		{overwrite: OverwriteNewer, replace: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.overwrite, func(t *testing.T) {
+			actions, err := Plan([]config.SyncRule{{Pattern: "file", Overwrite: tt.overwrite}}, src, dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(actions) != 1 {
+				t.Fatalf("Expected 1 action, got %d", len(actions))
+			}
+
+			if replaced := actions[0].Skip == "" && actions[0].Replace; replaced != tt.replace {
+				t.Errorf("Expected replace=%v, got %+v", tt.replace, actions[0])
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package filesync
+
+import (
+	"errors"
+	"os"
+)
+
+func cloneFile(src, dst string, mode os.FileMode) error {
+	return errors.New("reflinks are not supported on this platform")
+}
@@ -8,6 +8,8 @@ import (
 	"strings"
 )
 
+const OriginFetchRefspec = "+refs/heads/*:refs/remotes/origin/*"
+
 func CloneBare(url, name string) error {
 	cmd := exec.Command("git", "clone", "--bare", url, name)
 	cmd.Stdout = os.Stdout
@@ -16,7 +18,7 @@ func CloneBare(url, name string) error {
 }
 
 func ConfigureBareRepo(repoPath string) error {
-	cmd := exec.Command("git", "config", "remote.origin.fetch", "+refs/heads/*:refs/remotes/origin/*")
+	cmd := exec.Command("git", "config", "remote.origin.fetch", OriginFetchRefspec)
 	cmd.Dir = repoPath
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to configure fetch refs: %w", err)
@@ -3,9 +3,11 @@ package git
 import (
 	"bytes"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/mskelton/pool/internal/errors"
 )
@@ -32,12 +34,45 @@ func NewRepository(path string) (*Repository, error) {
 	if err == nil {
 		repo.DefaultBranch = strings.TrimPrefix(strings.TrimSpace(output), "refs/remotes/origin/")
 	} else {
-		repo.DefaultBranch = "main"
+		repo.DefaultBranch = repo.localDefaultBranch()
 	}
 
 	return repo, nil
 }
 
+// localDefaultBranch guesses the default branch of a repository without an
+// origin HEAD: main or master if either exists, otherwise the branch HEAD of
+// the main worktree points to.
+func (r *Repository) localDefaultBranch() string {
+	for _, branch := range []string{"main", "master"} {
+		if r.BranchExists(branch) {
+			return branch
+		}
+	}
+
+	if commonDir, err := r.commonDir(); err == nil {
+		head, err := os.ReadFile(filepath.Join(commonDir, "HEAD"))
+		if ref, ok := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: refs/heads/"); err == nil && ok {
+			return ref
+		}
+	}
+
+	return "main"
+}
+
+func (r *Repository) commonDir() (string, error) {
+	output, err := r.output("rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+
+	commonDir := strings.TrimSpace(output)
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(r.Path, commonDir)
+	}
+	return commonDir, nil
+}
+
 func (r *Repository) GetTopLevel() (string, error) {
 	output, err := r.output("rev-parse", "--show-toplevel")
 	if err != nil {
@@ -66,9 +101,99 @@ func (r *Repository) BranchExists(branch string) bool {
 	return err == nil
 }
 
+// RemoteBranchExists reports whether origin has branch, based on the
+// remote-tracking refs from the last fetch. Use FetchIfStale to refresh them.
 func (r *Repository) RemoteBranchExists(branch string) bool {
-	output, err := r.output("ls-remote", "--heads", "origin", branch)
-	return err == nil && strings.Contains(output, branch)
+	err := r.run("show-ref", "--verify", "--quiet", fmt.Sprintf("refs/remotes/origin/%s", branch))
+	return err == nil
+}
+
+// LocalBranches lists the local branches.
+func (r *Repository) LocalBranches() ([]string, error) {
+	output, err := r.output("for-each-ref", "--format=%(refname:short)", "refs/heads/")
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Fields(output), nil
+}
+
+// RemoteBranches lists the branches of origin known from the last fetch.
+func (r *Repository) RemoteBranches() ([]string, error) {
+	output, err := r.output("for-each-ref", "--format=%(refname)", "refs/remotes/origin/")
+	if err != nil {
+		return nil, err
+	}
+
+	var branches []string
+	for _, ref := range strings.Fields(output) {
+		branch := strings.TrimPrefix(ref, "refs/remotes/origin/")
+		if branch != "HEAD" {
+			branches = append(branches, branch)
+		}
+	}
+
+	return branches, nil
+}
+
+// fetchStamp is touched in the common git directory after every fetch by
+// FetchIfStale. Fetches from a linked worktree write FETCH_HEAD into that
+// worktree's own git directory, so FETCH_HEAD can't tell when the repository
+// was last fetched.
+const fetchStamp = "pool-last-fetch"
+
+// LastFetch returns when the repository was last fetched from any of its
+// worktrees, based on the modification time of the fetch stamp, or of
+// FETCH_HEAD in the common git directory if that is newer.
+func (r *Repository) LastFetch() (time.Time, bool) {
+	commonDir, err := r.commonDir()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var last time.Time
+	for _, name := range []string{fetchStamp, "FETCH_HEAD"} {
+		if info, err := os.Stat(filepath.Join(commonDir, name)); err == nil && info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+	}
+
+	return last, !last.IsZero()
+}
+
+// FetchIfStale fetches origin when the last fetch is older than maxAge, or
+// always when force is set.
+func (r *Repository) FetchIfStale(maxAge time.Duration, force bool) error {
+	if !force {
+		if last, ok := r.LastFetch(); ok && time.Since(last) < maxAge {
+			return nil
+		}
+	}
+
+	if err := r.FetchOriginPrune(); err != nil {
+		return err
+	}
+
+	return r.touchFetchStamp()
+}
+
+func (r *Repository) touchFetchStamp() error {
+	commonDir, err := r.commonDir()
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(commonDir, fetchStamp)
+	now := time.Now()
+
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		return errors.Wrap(err, "failed to record fetch time")
+	}
+	if err := os.Chtimes(path, now, now); err != nil {
+		return errors.Wrap(err, "failed to record fetch time")
+	}
+
+	return nil
 }
 
 func (r *Repository) GetCurrentBranch() (string, error) {
@@ -79,23 +204,96 @@ func (r *Repository) GetCurrentBranch() (string, error) {
 	return strings.TrimSpace(output), nil
 }
 
-func (r *Repository) GetMergedBranches() ([]string, error) {
-	output, err := r.output("branch", "-r", "--merged", fmt.Sprintf("origin/%s", r.DefaultBranch))
+// MergedLocalBranches lists the local branches whose tip is reachable from
+// ref, including ref itself if it is a local branch.
+func (r *Repository) MergedLocalBranches(ref string) ([]string, error) {
+	output, err := r.output("for-each-ref", "--merged="+ref, "--format=%(refname:short)", "refs/heads/")
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Fields(output), nil
+}
+
+func (r *Repository) ResolveCommit(ref string) (string, error) {
+	output, err := r.output("rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+func (r *Repository) IsAncestor(ancestor, descendant string) bool {
+	return r.run("merge-base", "--is-ancestor", ancestor, descendant) == nil
+}
+
+// UnpushedCommits lists the commits reachable from ref that are on no
+// remote-tracking branch, as "<hash> <subject>" lines. In a repository
+// without an origin, commits on the default branch count as pushed.
+func (r *Repository) UnpushedCommits(ref string) ([]string, error) {
+	args := []string{"log", "--oneline", ref, "--not", "--remotes"}
+	if !r.HasRemote("origin") {
+		args = append(args, r.DefaultBranch)
+	}
+	args = append(args, "--")
+
+	output, err := r.output(args...)
 	if err != nil {
 		return nil, err
 	}
 
-	lines := strings.Split(strings.TrimSpace(output), "\n")
-	branches := make([]string, 0, len(lines))
+	var commits []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if line != "" {
+			commits = append(commits, line)
+		}
+	}
+
+	return commits, nil
+}
+
+// DefaultBranchRef returns origin's default branch, or the local default
+// branch in a repository without an origin or before origin was fetched.
+func (r *Repository) DefaultBranchRef() string {
+	if r.HasRemote("origin") && r.RemoteBranchExists(r.DefaultBranch) {
+		return fmt.Sprintf("origin/%s", r.DefaultBranch)
+	}
+	return r.DefaultBranch
+}
 
-	for _, line := range lines {
-		branch := strings.TrimSpace(line)
-		if !strings.Contains(branch, r.DefaultBranch) && strings.HasPrefix(branch, "origin/") {
-			branches = append(branches, strings.TrimPrefix(branch, "origin/"))
+// DeleteBranch deletes a local branch. Without force, git refuses to delete
+// a branch that is not merged.
+func (r *Repository) DeleteBranch(branch string, force bool) error {
+	if force {
+		return r.run("branch", "-D", branch)
+	}
+	return r.run("branch", "-d", branch)
+}
+
+func (r *Repository) HasRemote(name string) bool {
+	output, err := r.output("remote")
+	if err != nil {
+		return false
+	}
+
+	for _, remote := range strings.Fields(output) {
+		if remote == name {
+			return true
 		}
 	}
+	return false
+}
 
-	return branches, nil
+func (r *Repository) GetConfig(key string) (string, error) {
+	output, err := r.output("config", "--get", key)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+func (r *Repository) SetConfig(key, value string) error {
+	return r.run("config", key, value)
 }
 
 func (r *Repository) FetchOrigin() error {
@@ -141,3 +339,41 @@ func RunInDir(dir string, args ...string) error {
 	}
 	return nil
 }
+
+func OutputInDir(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", errors.NewGitError(strings.Join(args, " "), err, stderr.String())
+	}
+
+	return stdout.String(), nil
+}
+
+// ChangedFiles lists the uncommitted and untracked changes in dir as short
+// status lines, e.g. " M README.md" or "?? notes.txt".
+func ChangedFiles(dir string) ([]string, error) {
+	output, err := OutputInDir(dir, "status", "--porcelain", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			files = append(files, line)
+		}
+	}
+
+	return files, nil
+}
+
+func IsDirty(dir string) bool {
+	output, err := OutputInDir(dir, "status", "--porcelain")
+	return err != nil || strings.TrimSpace(output) != ""
+}
@@ -5,6 +5,9 @@ import (
 	"os/exec"
 	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/mskelton/pool/internal/errors"
 )
 
 func TestRepository(t *testing.T) {
@@ -135,6 +138,96 @@ func TestWorktrees(t *testing.T) {
 	}
 }
 
+func TestInspectWorktree(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "inspect-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := initTestRepo(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := NewRepository(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	worktreePath := filepath.Join(tmpDir, "feature")
+	if err := repo.AddWorktree(worktreePath, "feature"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RunInDir(worktreePath, "commit", "--allow-empty", "-m", "Feature work"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(worktreePath, "test.txt"), []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(worktreePath, "new.txt"), []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RunInDir(tmpDir, "worktree", "lock", "--reason", "in use", worktreePath); err != nil {
+		t.Fatal(err)
+	}
+
+	worktrees, err := repo.ListWorktrees()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wt *Worktree
+	for i := range worktrees {
+		if worktrees[i].Branch == "feature" {
+			wt = &worktrees[i]
+		}
+	}
+	if wt == nil {
+		t.Fatal("Feature worktree not found")
+	}
+
+	if !wt.Locked || wt.LockReason != "in use" {
+		t.Errorf("Expected worktree locked with reason 'in use', got %v %q", wt.Locked, wt.LockReason)
+	}
+
+	if err := repo.InspectWorktree(wt); err != nil {
+		t.Fatal(err)
+	}
+
+	if wt.Subject != "Feature work" {
+		t.Errorf("Expected subject 'Feature work', got %q", wt.Subject)
+	}
+
+	if time.Since(wt.CommitTime) > time.Hour {
+		t.Errorf("Expected a recent commit time, got %s", wt.CommitTime)
+	}
+
+	if wt.Modified != 1 || wt.Untracked != 1 {
+		t.Errorf("Expected 1 modified and 1 untracked file, got %d and %d", wt.Modified, wt.Untracked)
+	}
+
+	if wt.BaseAhead != 1 || wt.BaseBehind != 0 {
+		t.Errorf("Expected 1 ahead and 0 behind the default branch, got %d and %d", wt.BaseAhead, wt.BaseBehind)
+	}
+
+	files, err := ChangedFiles(worktreePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 2 || files[0] != " M test.txt" || files[1] != "?? new.txt" {
+		t.Errorf("Expected changed test.txt and untracked new.txt, got %q", files)
+	}
+
+	if err := repo.UnlockWorktree(worktreePath); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestBranches(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "branch-test")
 	if err != nil {
@@ -177,6 +270,434 @@ func TestBranches(t *testing.T) {
 	if !repo.BranchExists("test-branch") {
 		t.Error("Expected test-branch to exist")
 	}
+
+	branches, err := repo.LocalBranches()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(branches) != 2 || branches[0] != "main" || branches[1] != "test-branch" {
+		t.Errorf("Expected local branches [main test-branch], got %v", branches)
+	}
+
+	if err := RunInDir(tmpDir, "commit", "--allow-empty", "-m", "unmerged"); err != nil {
+		t.Fatal(err)
+	}
+
+	merged, err := repo.MergedLocalBranches("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(merged) != 1 || merged[0] != "main" {
+		t.Errorf("Expected only main to be merged into main, got %v", merged)
+	}
+}
+
+func TestRemoteBranches(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "remote-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	origin := filepath.Join(tmpDir, "origin")
+	if err := os.Mkdir(origin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := initTestRepo(origin); err != nil {
+		t.Fatal(err)
+	}
+	if err := RunInDir(origin, "branch", "feature-x"); err != nil {
+		t.Fatal(err)
+	}
+
+	clone := filepath.Join(tmpDir, "clone")
+	if err := RunInDir(tmpDir, "clone", "--quiet", origin, clone); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := NewRepository(clone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !repo.RemoteBranchExists("feature-x") {
+		t.Error("Expected feature-x to exist on origin")
+	}
+
+	if repo.RemoteBranchExists("feat") {
+		t.Error("Expected feat not to match feature-x")
+	}
+
+	if _, ok := repo.LastFetch(); ok {
+		t.Error("Expected no fetch before FetchIfStale")
+	}
+
+	if err := repo.FetchIfStale(time.Hour, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := repo.LastFetch(); !ok {
+		t.Error("Expected FetchIfStale to fetch without a previous fetch")
+	}
+
+	if err := RunInDir(origin, "branch", "late"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.FetchIfStale(time.Hour, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if repo.RemoteBranchExists("late") {
+		t.Error("Expected recent fetch to be reused")
+	}
+
+	if err := repo.FetchIfStale(time.Hour, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if !repo.RemoteBranchExists("late") {
+		t.Error("Expected forced fetch to pick up late")
+	}
+
+	branches, err := repo.RemoteBranches()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(branches) != 3 {
+		t.Errorf("Expected 3 remote branches, got %v", branches)
+	}
+}
+
+func TestLastFetchFromWorktree(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "fetch-worktree-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	origin := filepath.Join(tmpDir, "origin")
+	if err := os.Mkdir(origin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := initTestRepo(origin); err != nil {
+		t.Fatal(err)
+	}
+
+	clone := filepath.Join(tmpDir, "clone")
+	if err := RunInDir(tmpDir, "clone", "--quiet", origin, clone); err != nil {
+		t.Fatal(err)
+	}
+
+	linked := filepath.Join(tmpDir, "linked")
+	if err := RunInDir(clone, "worktree", "add", "--quiet", "--detach", linked); err != nil {
+		t.Fatal(err)
+	}
+
+	worktree, err := NewRepository(linked)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := worktree.FetchIfStale(time.Hour, false); err != nil {
+		t.Fatal(err)
+	}
+
+	mainRepo, err := NewRepository(clone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, repo := range map[string]*Repository{"main": mainRepo, "linked": worktree} {
+		if last, ok := repo.LastFetch(); !ok || time.Since(last) > time.Minute {
+			t.Errorf("Expected the %s worktree to see the fetch from the linked worktree, got %v", name, last)
+		}
+	}
+
+	if err := RunInDir(origin, "branch", "late"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mainRepo.FetchIfStale(time.Hour, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if mainRepo.RemoteBranchExists("late") {
+		t.Error("Expected the fetch from the linked worktree to be reused")
+	}
+}
+
+func TestUnpushedCommits(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "unpushed-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := initTestRepo(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := NewRepository(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RunInDir(tmpDir, "checkout", "-b", "topic"); err != nil {
+		t.Fatal(err)
+	}
+
+	commits, err := repo.UnpushedCommits("topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(commits) != 0 {
+		t.Errorf("Expected commits on the default branch to count as pushed, got %v", commits)
+	}
+
+	if err := RunInDir(tmpDir, "commit", "--allow-empty", "-m", "Local work"); err != nil {
+		t.Fatal(err)
+	}
+
+	commits, err = repo.UnpushedCommits("topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(commits) != 1 {
+		t.Errorf("Expected 1 unpushed commit, got %v", commits)
+	}
+}
+
+func TestMergeStatus(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "merge-status-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := initTestRepo(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := NewRepository(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := repo.DefaultBranch
+
+	commit := func(file, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(tmpDir, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := RunInDir(tmpDir, "add", file); err != nil {
+			t.Fatal(err)
+		}
+		if err := RunInDir(tmpDir, "commit", "-m", "Change "+file); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkout := func(args ...string) {
+		t.Helper()
+		if err := RunInDir(tmpDir, append([]string{"checkout", "-q"}, args...)...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkout("-b", "merged")
+	commit("merged.txt", "merged")
+	checkout("-b", "squashed", base)
+	commit("squashed1.txt", "one")
+	commit("squashed2.txt", "two")
+	checkout("-b", "rebased", base)
+	commit("rebased1.txt", "one")
+	commit("rebased2.txt", "two")
+	checkout("-b", "open", base)
+	commit("open.txt", "open")
+
+	checkout(base)
+	commit("other.txt", "other")
+	if err := RunInDir(tmpDir, "merge", "--no-edit", "merged"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RunInDir(tmpDir, "merge", "--squash", "squashed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RunInDir(tmpDir, "commit", "-m", "Squashed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RunInDir(tmpDir, "cherry-pick", base+"..rebased"); err != nil {
+		t.Fatal(err)
+	}
+	checkout("-B", "fresh", base)
+	checkout(base)
+
+	tests := map[string]MergeReason{
+		"merged":   Merged,
+		"squashed": SquashMerged,
+		"rebased":  RebaseMerged,
+		"open":     "",
+		"fresh":    "",
+	}
+
+	for branch, expected := range tests {
+		reason, err := repo.MergeStatus(branch, base)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if reason != expected {
+			t.Errorf("Expected %s to be %q, got %q", branch, expected, reason)
+		}
+	}
+}
+
+func TestDefaultBranchWithoutRemote(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "default-branch-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := initTestRepo(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RunInDir(tmpDir, "branch", "-M", "trunk"); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := NewRepository(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if repo.DefaultBranch != "trunk" {
+		t.Errorf("Expected default branch trunk, got %s", repo.DefaultBranch)
+	}
+
+	if err := RunInDir(tmpDir, "branch", "master"); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err = NewRepository(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if repo.DefaultBranch != "master" {
+		t.Errorf("Expected default branch master, got %s", repo.DefaultBranch)
+	}
+}
+
+func TestLastModified(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "last-modified-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := initTestRepo(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, ".gitignore"), []byte("node_modules/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := RunInDir(tmpDir, "add", ".gitignore"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RunInDir(tmpDir, "commit", "-m", "Ignore dependencies"); err != nil {
+		t.Fatal(err)
+	}
+
+	old := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	for _, file := range []string{"test.txt", ".gitignore", ".git/index", ".git/logs/HEAD"} {
+		if err := os.Chtimes(filepath.Join(tmpDir, file), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Join(tmpDir, "node_modules", "dep"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "node_modules", "dep", "index.js"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	last, err := LastModified(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !last.Equal(old) {
+		t.Errorf("Expected ignored files not to count, got %v instead of %v", last, old)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "notes.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	last, err = LastModified(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if time.Since(last) > time.Minute {
+		t.Errorf("Expected a new untracked file to count, got %v", last)
+	}
+}
+
+func TestMoveWorktree(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "move-worktree-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := initTestRepo(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := NewRepository(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	worktree := filepath.Join(tmpDir, "worktree")
+	if err := repo.AddDetachedWorktree(worktree, repo.DefaultBranch); err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(tmpDir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = repo.MoveWorktree(worktree, file)
+	if err == nil || errors.Is(err, errors.ErrCrossDevice) {
+		t.Errorf("Expected moving onto a file to fail with a plain git error, got %v", err)
+	}
+
+	otherDevice, err := os.MkdirTemp("/dev/shm", "move-worktree-test")
+	if err != nil {
+		t.Skip("no /dev/shm to move a worktree across filesystems")
+	}
+	defer os.RemoveAll(otherDevice)
+
+	err = repo.MoveWorktree(worktree, filepath.Join(otherDevice, "worktree"))
+	if err == nil {
+		t.Skip("/dev/shm is on the same filesystem")
+	}
+	if !errors.Is(err, errors.ErrCrossDevice) {
+		t.Errorf("Expected moving across filesystems to fail with ErrCrossDevice, got %v", err)
+	}
 }
 
 func initTestRepo(dir string) error {
@@ -0,0 +1,137 @@
+package git
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+
+	"github.com/mskelton/pool/internal/errors"
+)
+
+// MergeReason says how a branch was found to be merged.
+type MergeReason string
+
+const (
+	// Merged means the tip of the branch is on the base.
+	Merged MergeReason = "merged"
+	// SquashMerged means the combined diff of the branch is a commit on the
+	// base.
+	SquashMerged MergeReason = "squash-merged"
+	// RebaseMerged means every commit of the branch has a commit with the
+	// same patch-id on the base.
+	RebaseMerged MergeReason = "rebase-merged"
+)
+
+// MergeStatus reports how ref was merged into base, or "" if it wasn't.
+// Squash and rebase merges are found by comparing patch-ids with the commits
+// on base since ref forked off it, so they are only recognized when they
+// applied without changes. A branch without commits of its own is never
+// merged, see HasOwnCommits.
+func (r *Repository) MergeStatus(ref, base string) (MergeReason, error) {
+	if r.IsAncestor(ref, base) {
+		if !r.HasOwnCommits(ref) {
+			return "", nil
+		}
+		return Merged, nil
+	}
+
+	forkPoint, err := r.output("merge-base", base, ref)
+	if err != nil {
+		return "", err
+	}
+	forkPoint = strings.TrimSpace(forkPoint)
+
+	ids, err := r.patchIDs("log", "-p", "--no-merges", forkPoint+".."+base, "--")
+	if err != nil || len(ids) == 0 {
+		return "", err
+	}
+
+	landed := make(map[string]bool)
+	for _, id := range ids {
+		landed[id] = true
+	}
+
+	combined, err := r.patchIDs("diff", forkPoint, ref, "--")
+	if err != nil {
+		return "", err
+	}
+	if len(combined) == 1 && landed[combined[0]] {
+		return SquashMerged, nil
+	}
+
+	commits, err := r.patchIDs("log", "-p", "--no-merges", base+".."+ref, "--")
+	if err != nil || len(commits) == 0 {
+		return "", err
+	}
+	for _, id := range commits {
+		if !landed[id] {
+			return "", nil
+		}
+	}
+
+	return RebaseMerged, nil
+}
+
+// HasOwnCommits reports whether the local branch ever had commits of its
+// own, so that a branch just created from the default branch isn't taken for
+// a merged one. It has if it was pushed, or if its reflog shows it moved other
+// than by being created, reset or renamed. Without a reflog, as in bare
+// repositories, only pushing counts. Refs that aren't local branches always
+// have.
+func (r *Repository) HasOwnCommits(branch string) bool {
+	if !r.BranchExists(branch) || r.RemoteBranchExists(branch) {
+		return true
+	}
+	if r.run("config", "--get", "branch."+branch+".merge") == nil {
+		return true
+	}
+
+	output, err := r.output("log", "-g", "--format=%gs", "refs/heads/"+branch, "--")
+	if err != nil {
+		return false
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case line == "",
+			strings.HasPrefix(line, "branch: Created from"),
+			strings.HasPrefix(line, "branch: Reset to"),
+			strings.HasPrefix(line, "Branch: renamed"):
+		default:
+			return true
+		}
+	}
+
+	return false
+}
+
+// patchIDs returns the stable patch-ids of the patches git prints with args.
+// Patches that change nothing have none.
+func (r *Repository) patchIDs(args ...string) ([]string, error) {
+	args = append([]string{args[0], "--no-color", "--no-ext-diff"}, args[1:]...)
+	patch, err := r.output(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("git", "patch-id", "--stable")
+	cmd.Dir = r.Path
+	cmd.Stdin = strings.NewReader(patch)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, errors.NewGitError("patch-id --stable", err, stderr.String())
+	}
+
+	var ids []string
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			ids = append(ids, fields[0])
+		}
+	}
+
+	return ids, nil
+}
@@ -2,14 +2,37 @@ package git
 
 import (
 	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/mskelton/pool/internal/errors"
 )
 
 type Worktree struct {
-	Path   string
-	Branch string
-	Commit string
-	Bare   bool
+	Path       string
+	Branch     string
+	Commit     string
+	Bare       bool
+	Detached   bool
+	Locked     bool
+	LockReason string
+	Prunable   bool
+
+	// The fields below are filled in by InspectWorktree.
+	CommitTime time.Time
+	Subject    string
+	Modified   int
+	Untracked  int
+	Upstream   string
+	Ahead      int
+	Behind     int
+	// BaseAhead and BaseBehind count commits relative to the default branch.
+	BaseAhead  int
+	BaseBehind int
 }
 
 func (r *Repository) ListWorktrees() ([]Worktree, error) {
@@ -36,6 +59,13 @@ func (r *Repository) ListWorktrees() ([]Worktree, error) {
 			current.Commit = strings.TrimPrefix(line, "HEAD ")
 		} else if line == "bare" {
 			current.Bare = true
+		} else if line == "detached" {
+			current.Detached = true
+		} else if line == "locked" || strings.HasPrefix(line, "locked ") {
+			current.Locked = true
+			current.LockReason = strings.TrimPrefix(strings.TrimPrefix(line, "locked"), " ")
+		} else if line == "prunable" || strings.HasPrefix(line, "prunable ") {
+			current.Prunable = true
 		}
 	}
 
@@ -46,6 +76,59 @@ func (r *Repository) ListWorktrees() ([]Worktree, error) {
 	return worktrees, nil
 }
 
+// InspectWorktree fills in the HEAD commit details, working tree changes and
+// ahead/behind counts of wt. These take several git calls per worktree, so
+// ListWorktrees leaves them out.
+func (r *Repository) InspectWorktree(wt *Worktree) error {
+	output, err := OutputInDir(wt.Path, "log", "-1", "--format=%ct%x00%s", "HEAD")
+	if err != nil {
+		return err
+	}
+
+	timestamp, subject, _ := strings.Cut(strings.TrimSpace(output), "\x00")
+	if seconds, err := strconv.ParseInt(timestamp, 10, 64); err == nil {
+		wt.CommitTime = time.Unix(seconds, 0)
+	}
+	wt.Subject = subject
+
+	output, err = OutputInDir(wt.Path, "status", "--porcelain")
+	if err != nil {
+		return err
+	}
+
+	wt.Modified, wt.Untracked = 0, 0
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case strings.HasPrefix(line, "??"):
+			wt.Untracked++
+		case strings.TrimSpace(line) != "":
+			wt.Modified++
+		}
+	}
+
+	wt.Upstream = ""
+	if wt.Branch != "" {
+		if output, err := OutputInDir(wt.Path, "rev-parse", "--abbrev-ref", "@{upstream}"); err == nil {
+			wt.Upstream = strings.TrimSpace(output)
+			wt.Ahead, wt.Behind = r.aheadBehind(wt.Path, wt.Upstream)
+		}
+	}
+
+	wt.BaseAhead, wt.BaseBehind = r.aheadBehind(wt.Path, r.DefaultBranchRef())
+	return nil
+}
+
+func (r *Repository) aheadBehind(dir, ref string) (int, int) {
+	output, err := OutputInDir(dir, "rev-list", "--left-right", "--count", fmt.Sprintf("HEAD...%s", ref))
+	if err != nil {
+		return 0, 0
+	}
+
+	var ahead, behind int
+	fmt.Sscanf(output, "%d %d", &ahead, &behind)
+	return ahead, behind
+}
+
 func (r *Repository) AddWorktree(path, branch string, opts ...string) error {
 	args := []string{"worktree", "add"}
 	args = append(args, opts...)
@@ -57,12 +140,34 @@ func (r *Repository) AddWorktreeFromBranch(path, branch, source string) error {
 	return r.run("worktree", "add", path, "-b", branch, source)
 }
 
+func (r *Repository) AddWorktreeForBranch(path, branch string) error {
+	return r.run("worktree", "add", path, branch)
+}
+
+func (r *Repository) AddDetachedWorktree(path, source string) error {
+	return r.run("worktree", "add", "--detach", path, source)
+}
+
 func (r *Repository) RemoveWorktree(path string) error {
 	return r.run("worktree", "remove", path)
 }
 
+func (r *Repository) ForceRemoveWorktree(path string) error {
+	return r.run("worktree", "remove", "--force", path)
+}
+
+func (r *Repository) UnlockWorktree(path string) error {
+	return r.run("worktree", "unlock", path)
+}
+
+// MoveWorktree moves the worktree at from to to. git can't move a worktree
+// to another filesystem, which fails with errors.ErrCrossDevice.
 func (r *Repository) MoveWorktree(from, to string) error {
-	return r.run("worktree", "move", from, to)
+	_, err := r.output("worktree", "move", from, to)
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "cross-device link") {
+		return fmt.Errorf("%w: %w", errors.ErrCrossDevice, err)
+	}
+	return err
 }
 
 func (r *Repository) RepairWorktrees() error {
@@ -83,3 +188,45 @@ func (r *Repository) CheckoutBranch(branch string, create bool) error {
 func (r *Repository) CheckoutNewBranch(branch, source string) error {
 	return r.run("checkout", "-B", branch, source)
 }
+
+// StartBranch checks out a new branch at source without tracking it, so that
+// a branch started from origin's default branch doesn't pull from it.
+func (r *Repository) StartBranch(branch, source string) error {
+	return r.run("checkout", "--no-track", "-B", branch, source)
+}
+
+// LastModified returns when the worktree at dir was last worked in: the
+// latest modification time of its tracked files and untracked files that
+// aren't ignored, its index and its HEAD reflog. Ignored files such as
+// dependencies and build output don't count, as they are often regenerated
+// without anyone working in the worktree.
+func LastModified(dir string) (time.Time, error) {
+	output, err := OutputInDir(dir, "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	if err != nil {
+		return time.Time{}, err
+	}
+	files := strings.Split(strings.TrimSuffix(output, "\x00"), "\x00")
+
+	output, err = OutputInDir(dir, "rev-parse", "--git-path", "index", "--git-path", "logs/HEAD")
+	if err != nil {
+		return time.Time{}, err
+	}
+	files = append(files, strings.Split(strings.TrimSpace(output), "\n")...)
+
+	var latest time.Time
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+
+		// Deleted files and a missing reflog are fine to skip.
+		if info, err := os.Lstat(file); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
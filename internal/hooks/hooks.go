@@ -0,0 +1,188 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/mskelton/pool/internal/errors"
+	"github.com/mskelton/pool/internal/logger"
+)
+
+const (
+	HooksDir       = ".pool/hooks"
+	DefaultTimeout = 60 * time.Second
+)
+
+type Event string
+
+const (
+	PreClaim   Event = "pre-claim"
+	PostClaim  Event = "post-claim"
+	PreRemove  Event = "pre-remove"
+	PostRefill Event = "post-refill"
+)
+
+var Events = []Event{PreClaim, PostClaim, PreRemove, PostRefill}
+
+func IsEvent(name string) bool {
+	for _, event := range Events {
+		if string(event) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Env describes the pool operation a hook is run for. It is passed to hooks
+// as POOL_* environment variables.
+type Env struct {
+	Branch       string
+	WorktreePath string
+	Entry        string
+	RepoRoot     string
+}
+
+func (e Env) environ(event Event) []string {
+	return append(os.Environ(),
+		"POOL_EVENT="+string(event),
+		"POOL_BRANCH="+e.Branch,
+		"POOL_WORKTREE_PATH="+e.WorktreePath,
+		"POOL_ENTRY="+e.Entry,
+		"POOL_REPO_ROOT="+e.RepoRoot,
+	)
+}
+
+type HookError struct {
+	Event  Event
+	Hook   string
+	Output string
+	Err    error
+}
+
+func (e *HookError) Error() string {
+	msg := fmt.Sprintf("%s hook %s failed: %v", e.Event, e.Hook, e.Err)
+	if output := strings.TrimSpace(e.Output); output != "" {
+		msg += "\n" + output
+	}
+	return msg
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// Runner runs the hooks for an event: the executable named after the event
+// in the hooks directory, followed by the commands configured for it.
+type Runner struct {
+	Dir      string
+	Commands map[string][]string
+	Timeout  time.Duration
+}
+
+func NewRunner(repoRoot string, commands map[string][]string, timeout time.Duration) *Runner {
+	return &Runner{
+		Dir:      filepath.Join(repoRoot, HooksDir),
+		Commands: commands,
+		Timeout:  timeout,
+	}
+}
+
+// Run runs every hook for event, stopping at the first failure. Callers
+// should abort the operation when a pre-* hook fails.
+func (r *Runner) Run(event Event, env Env) error {
+	if r == nil {
+		return nil
+	}
+
+	if path := filepath.Join(r.Dir, string(event)); isExecutable(path) {
+		if err := r.run(event, path, []string{path}, env); err != nil {
+			return err
+		}
+	}
+
+	for _, command := range r.Commands[string(event)] {
+		if err := r.run(event, command, shellArgs(command), env); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Runner) run(event Event, name string, args []string, env Env) error {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = env.RepoRoot
+	cmd.Env = env.environ(event)
+	cmd.WaitDelay = time.Second
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	logger.Info("Running %s hook: %s", event, name)
+	err := cmd.Run()
+
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+
+	if err != nil {
+		return &HookError{Event: event, Hook: name, Output: output.String(), Err: err}
+	}
+
+	for _, line := range strings.Split(strings.TrimRight(output.String(), "\n"), "\n") {
+		if line != "" {
+			logger.Plain("  %s", line)
+		}
+	}
+
+	return nil
+}
+
+// ShellCommand runs command through the platform's shell.
+func ShellCommand(command string) *exec.Cmd {
+	args := shellArgs(command)
+	return exec.Command(args[0], args[1:]...)
+}
+
+func shellArgs(command string) []string {
+	if runtime.GOOS == "windows" {
+		return []string{"cmd", "/C", command}
+	}
+	return []string{"sh", "-c", command}
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+
+	if runtime.GOOS != "windows" && info.Mode()&0111 == 0 {
+		logger.Warning("Skipping hook %s: not executable", path)
+		return false
+	}
+
+	return true
+}
+
+// Abort wraps a failed pre-* hook so the caller can report that the
+// operation was cancelled rather than failed.
+func Abort(op string, err error) error {
+	return errors.Wrapf(err, "%s aborted", op)
+}
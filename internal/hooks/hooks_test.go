@@ -0,0 +1,116 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mskelton/pool/internal/errors"
+)
+
+func TestRunnerEnvironment(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts require sh")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "hooks-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	hooksDir := filepath.Join(tmpDir, HooksDir)
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	script := "#!/bin/sh\necho \"$POOL_BRANCH $POOL_ENTRY $POOL_WORKTREE_PATH\" > hook.out\n"
+	if err := os.WriteFile(filepath.Join(hooksDir, string(PostClaim)), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := NewRunner(tmpDir, map[string][]string{
+		string(PostClaim): {"echo \"$POOL_EVENT\" > config.out"},
+	}, time.Second)
+
+	err = runner.Run(PostClaim, Env{
+		Branch:       "feature",
+		WorktreePath: "/tmp/feature",
+		Entry:        "pool-1",
+		RepoRoot:     tmpDir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, "hook.out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(data)) != "feature pool-1 /tmp/feature" {
+		t.Errorf("Unexpected hook environment: %q", data)
+	}
+
+	data, err = os.ReadFile(filepath.Join(tmpDir, "config.out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(data)) != string(PostClaim) {
+		t.Errorf("Expected configured hook to run for %s, got %q", PostClaim, data)
+	}
+}
+
+func TestRunnerFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands require sh")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "hooks-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	tests := []struct {
+		name     string
+		command  string
+		contains string
+	}{
+		{
+			name:     "Non-zero exit",
+			command:  "echo not allowed; exit 3",
+			contains: "not allowed",
+		},
+		{
+			name:     "Timeout",
+			command:  "sleep 5",
+			contains: "timed out",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := NewRunner(tmpDir, map[string][]string{
+				string(PreClaim): {tt.command, "touch ran-after"},
+			}, 200*time.Millisecond)
+
+			err := runner.Run(PreClaim, Env{RepoRoot: tmpDir})
+
+			var hookErr *HookError
+			if !errors.As(err, &hookErr) {
+				t.Fatalf("Expected hook error, got %v", err)
+			}
+
+			if !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("Expected error to contain %q, got %q", tt.contains, err.Error())
+			}
+
+			if _, err := os.Stat(filepath.Join(tmpDir, "ran-after")); !os.IsNotExist(err) {
+				t.Error("Expected hooks after a failure not to run")
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/mskelton/pool/internal/errors"
+)
+
+// Version is bumped whenever a document changes in a way that is not
+// backwards compatible.
+const Version = 1
+
+const (
+	Text = "text"
+	JSON = "json"
+)
+
+// Document is the single JSON value a command writes to stdout in JSON mode.
+// Exactly one of Data and Error is set.
+type Document struct {
+	Version int    `json:"version"`
+	Command string `json:"command"`
+	Data    any    `json:"data,omitempty"`
+	Error   *Error `json:"error,omitempty"`
+}
+
+// Error is a structured error. Type is "git", "validation", "locked",
+// "offline", "no_remote" or "error", and the remaining fields depend on it.
+type Error struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Command string `json:"command,omitempty"`
+	Output  string `json:"output,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Value   string `json:"value,omitempty"`
+}
+
+// NewError describes err, keeping the details of git and validation errors.
+func NewError(err error) *Error {
+	e := &Error{Type: "error", Message: err.Error()}
+
+	var gitErr *errors.GitError
+	var validationErr *errors.ValidationError
+
+	switch {
+	case errors.As(err, &gitErr):
+		e.Type = "git"
+		e.Command = gitErr.Command
+		e.Output = gitErr.Output
+	case errors.As(err, &validationErr):
+		e.Type = "validation"
+		e.Field = validationErr.Field
+		e.Value = validationErr.Value
+	case errors.Is(err, errors.ErrPoolLocked):
+		e.Type = "locked"
+	case errors.Is(err, errors.ErrOffline):
+		e.Type = "offline"
+	case errors.Is(err, errors.ErrNoRemote):
+		e.Type = "no_remote"
+	}
+
+	return e
+}
+
+func Write(w io.Writer, doc Document) error {
+	doc.Version = Version
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
@@ -0,0 +1,76 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/mskelton/pool/internal/errors"
+)
+
+func TestNewError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Error
+	}{
+		{
+			name: "Git error",
+			err:  errors.Wrap(errors.NewGitError("fetch origin", fmt.Errorf("exit status 128"), "fatal: no remote"), "failed to fetch"),
+			want: Error{Type: "git", Command: "fetch origin", Output: "fatal: no remote"},
+		},
+		{
+			name: "Validation error",
+			err:  errors.NewValidationError("pool_size", "0", "must be at least 1"),
+			want: Error{Type: "validation", Field: "pool_size", Value: "0"},
+		},
+		{
+			name: "Locked",
+			err:  errors.Wrap(errors.ErrPoolLocked, "timed out"),
+			want: Error{Type: "locked"},
+		},
+		{
+			name: "No remote",
+			err:  errors.Wrapf(errors.ErrNoRemote, "clean merged compares branches against origin/%s", "main"),
+			want: Error{Type: "no_remote"},
+		},
+		{
+			name: "Other error",
+			err:  errors.ErrNoPoolAvailable,
+			want: Error{Type: "error"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewError(tt.err)
+			tt.want.Message = tt.err.Error()
+
+			if *got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, *got)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, Document{Command: "status", Data: map[string]int{"available": 2}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Version int            `json:"version"`
+		Command string         `json:"command"`
+		Data    map[string]int `json:"data"`
+		Error   *Error         `json:"error"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Version != Version || doc.Command != "status" || doc.Data["available"] != 2 || doc.Error != nil {
+		t.Errorf("Unexpected document: %s", buf.String())
+	}
+}
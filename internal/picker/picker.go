@@ -0,0 +1,332 @@
+package picker
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"github.com/mskelton/pool/internal/errors"
+)
+
+// MaxRows is the number of items shown at once.
+const MaxRows = 10
+
+type Action int
+
+const (
+	Select Action = iota
+	Open
+	Release
+	Remove
+)
+
+type Item struct {
+	// Text is what the query is matched against.
+	Text string
+	// Marks are shown in a fixed-width column before Text.
+	Marks string
+	// Detail is shown dimmed after Text.
+	Detail string
+}
+
+// Result is the item the picker was closed on, as an index into the items,
+// and the key that closed it.
+type Result struct {
+	Index  int
+	Action Action
+}
+
+type Key int
+
+const (
+	KeyRune Key = iota
+	KeyEnter
+	KeyUp
+	KeyDown
+	KeyBackspace
+	KeyClear
+	KeyCancel
+	KeyOpen
+	KeyRelease
+	KeyRemove
+	KeyUnknown
+)
+
+// Picker is the state of a fuzzy finder. It is separate from the terminal so
+// that it can be driven by Handle in tests.
+type Picker struct {
+	Header  string
+	items   []Item
+	query   []rune
+	matches []int
+	cursor  int
+	offset  int
+}
+
+func New(items []Item) *Picker {
+	p := &Picker{items: items}
+	p.filter()
+	return p
+}
+
+// Query returns the current query.
+func (p *Picker) Query() string {
+	return string(p.query)
+}
+
+// Matches returns the indexes of the items matching the query, best first.
+func (p *Picker) Matches() []int {
+	return p.matches
+}
+
+// Handle applies a key press and returns the result once the picker is
+// closed. A nil result with done set means it was cancelled.
+func (p *Picker) Handle(key Key, r rune) (result *Result, done bool) {
+	switch key {
+	case KeyRune:
+		p.query = append(p.query, r)
+		p.filter()
+	case KeyBackspace:
+		if len(p.query) > 0 {
+			p.query = p.query[:len(p.query)-1]
+			p.filter()
+		}
+	case KeyClear:
+		p.query = nil
+		p.filter()
+	case KeyUp:
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case KeyDown:
+		if p.cursor < len(p.matches)-1 {
+			p.cursor++
+		}
+	case KeyCancel:
+		return nil, true
+	case KeyEnter, KeyOpen, KeyRelease, KeyRemove:
+		if len(p.matches) == 0 {
+			return nil, false
+		}
+		actions := map[Key]Action{KeyEnter: Select, KeyOpen: Open, KeyRelease: Release, KeyRemove: Remove}
+		return &Result{Index: p.matches[p.cursor], Action: actions[key]}, true
+	}
+
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	} else if p.cursor >= p.offset+MaxRows {
+		p.offset = p.cursor - MaxRows + 1
+	}
+
+	return nil, false
+}
+
+func (p *Picker) filter() {
+	type scored struct {
+		index int
+		score int
+	}
+
+	var results []scored
+	for i, item := range p.items {
+		if score, ok := Match(string(p.query), item.Text); ok {
+			results = append(results, scored{i, score})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].score > results[j].score
+	})
+
+	p.matches = p.matches[:0]
+	for _, result := range results {
+		p.matches = append(p.matches, result.index)
+	}
+
+	p.cursor = 0
+	p.offset = 0
+}
+
+// Match reports whether the characters of query appear in text in order,
+// ignoring case, and scores how well: consecutive characters and characters
+// at the start of text or of a path segment or word score higher, and
+// shorter texts win ties.
+func Match(query, text string) (int, bool) {
+	if query == "" {
+		return 0, true
+	}
+
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(text))
+
+	score := 0
+	qi := 0
+	prev := -2
+
+	for ti := 0; ti < len(t) && qi < len(q); ti++ {
+		if t[ti] != q[qi] {
+			continue
+		}
+
+		score++
+		if ti == prev+1 {
+			score += 5
+		}
+		if ti == 0 || strings.ContainsRune("/-_. ", t[ti-1]) {
+			score += 3
+		}
+
+		prev = ti
+		qi++
+	}
+
+	if qi < len(q) {
+		return 0, false
+	}
+
+	return score*100 - len(t), true
+}
+
+// Run shows the picker on the terminal in and out until an item is chosen or
+// it is cancelled, in which case the result is nil.
+func (p *Picker) Run(in, out *os.File) (*Result, error) {
+	restore, err := makeRaw(int(in.Fd()))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to set up terminal")
+	}
+	defer restore()
+
+	fmt.Fprint(out, "\x1b[?25l")
+	defer fmt.Fprint(out, "\r\x1b[J\x1b[?25h")
+
+	buf := make([]byte, 64)
+	for {
+		p.render(out, terminalWidth(int(out.Fd())))
+
+		n, err := in.Read(buf)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read from terminal")
+		}
+
+		for input := buf[:n]; len(input) > 0; {
+			key, r, size := parseKey(input)
+			input = input[size:]
+
+			if result, done := p.Handle(key, r); done {
+				return result, nil
+			}
+		}
+	}
+}
+
+func (p *Picker) render(w io.Writer, width int) {
+	var b strings.Builder
+
+	b.WriteString("\r\x1b[J")
+	fmt.Fprintf(&b, "%s %s", color.CyanString(">"), string(p.query))
+	fmt.Fprintf(&b, "  %s", color.New(color.Faint).Sprintf("%d/%d", len(p.matches), len(p.items)))
+
+	lines := 1
+	if p.Header != "" {
+		fmt.Fprintf(&b, "\r\n%s", color.New(color.Faint).Sprint(truncate(p.Header, width)))
+		lines++
+	}
+
+	for row := p.offset; row < len(p.matches) && row < p.offset+MaxRows; row++ {
+		item := p.items[p.matches[row]]
+
+		// Lines must not wrap, or moving back up to the query would be off.
+		available := width - 6
+		text := truncate(item.Text, available)
+		detail := truncate(item.Detail, available-utf8.RuneCountInString(text)-2)
+
+		line := fmt.Sprintf("%-3s %s  %s", item.Marks, text, color.New(color.Faint).Sprint(detail))
+		if row == p.cursor {
+			line = color.New(color.Bold).Sprint("▌ ") + line
+		} else {
+			line = "  " + line
+		}
+
+		fmt.Fprintf(&b, "\r\n%s", line)
+		lines++
+	}
+
+	// Move back to the end of the query line.
+	if lines > 1 {
+		fmt.Fprintf(&b, "\x1b[%dA", lines-1)
+	}
+	fmt.Fprintf(&b, "\r\x1b[%dC", 2+len(p.query))
+
+	io.WriteString(w, b.String())
+}
+
+// parseKey decodes the first key press in input and returns how many bytes
+// it used.
+func parseKey(input []byte) (Key, rune, int) {
+	switch input[0] {
+	case '\r', '\n':
+		return KeyEnter, 0, 1
+	case 127, '\b':
+		return KeyBackspace, 0, 1
+	case 3, 4: // ctrl-c, ctrl-d
+		return KeyCancel, 0, 1
+	case 14: // ctrl-n
+		return KeyDown, 0, 1
+	case 16: // ctrl-p
+		return KeyUp, 0, 1
+	case 21: // ctrl-u
+		return KeyClear, 0, 1
+	case 15: // ctrl-o
+		return KeyOpen, 0, 1
+	case 18: // ctrl-r
+		return KeyRelease, 0, 1
+	case 24: // ctrl-x
+		return KeyRemove, 0, 1
+	case 27:
+		if len(input) == 1 {
+			return KeyCancel, 0, 1
+		}
+		if len(input) >= 3 && (input[1] == '[' || input[1] == 'O') {
+			// Skip parameters up to the final byte, e.g. the "3~" of delete.
+			end := 2
+			for end < len(input)-1 && input[end] >= 0x30 && input[end] <= 0x3f {
+				end++
+			}
+
+			switch input[end] {
+			case 'A':
+				return KeyUp, 0, end + 1
+			case 'B':
+				return KeyDown, 0, end + 1
+			}
+			return KeyUnknown, 0, end + 1
+		}
+		return KeyUnknown, 0, len(input)
+	}
+
+	r, size := utf8.DecodeRune(input)
+	if r == utf8.RuneError || !unicode.IsPrint(r) {
+		return KeyUnknown, 0, size
+	}
+	return KeyRune, r, size
+}
+
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width == 1 {
+		return "…"
+	}
+	return string(runes[:width-1]) + "…"
+}
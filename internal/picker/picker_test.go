@@ -0,0 +1,122 @@
+package picker
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		query string
+		text  string
+		match bool
+	}{
+		{"", "anything", true},
+		{"fl", "feature/login", true},
+		{"FEAT", "feature/login", true},
+		{"lf", "feature/login", false},
+		{"featurex", "feature", false},
+	}
+
+	for _, tt := range tests {
+		if _, ok := Match(tt.query, tt.text); ok != tt.match {
+			t.Errorf("Match(%q, %q) = %v, expected %v", tt.query, tt.text, ok, tt.match)
+		}
+	}
+
+	prefix, _ := Match("log", "login-page")
+	scattered, _ := Match("log", "release/big-form")
+	if prefix <= scattered {
+		t.Errorf("Expected consecutive prefix match to score higher, got %d <= %d", prefix, scattered)
+	}
+
+	short, _ := Match("fix", "fix")
+	long, _ := Match("fix", "fix-build")
+	if short <= long {
+		t.Errorf("Expected shorter text to win a tie, got %d <= %d", short, long)
+	}
+}
+
+func TestHandle(t *testing.T) {
+	items := []Item{
+		{Text: "main"},
+		{Text: "feature/login"},
+		{Text: "feature/logout"},
+		{Text: "fix/layout"},
+	}
+
+	p := New(items)
+	if len(p.Matches()) != len(items) {
+		t.Fatalf("Expected all items to match an empty query, got %v", p.Matches())
+	}
+
+	for _, r := range "logo" {
+		p.Handle(KeyRune, r)
+	}
+
+	if got := p.Matches(); len(got) != 1 || got[0] != 2 {
+		t.Fatalf("Expected only feature/logout to match %q, got %v", p.Query(), got)
+	}
+
+	p.Handle(KeyBackspace, 0)
+	if got := p.Matches(); len(got) != 2 {
+		t.Fatalf("Expected two matches for %q, got %v", p.Query(), got)
+	}
+
+	p.Handle(KeyDown, 0)
+	p.Handle(KeyDown, 0)
+	result, done := p.Handle(KeyRelease, 0)
+	if !done || result == nil || result.Action != Release || result.Index != p.Matches()[1] {
+		t.Errorf("Expected release of the second match, got %+v", result)
+	}
+
+	p.Handle(KeyClear, 0)
+	for _, r := range "zzz" {
+		p.Handle(KeyRune, r)
+	}
+	if result, done := p.Handle(KeyEnter, 0); done || result != nil {
+		t.Errorf("Expected enter without matches to be ignored, got %+v", result)
+	}
+
+	if result, done := p.Handle(KeyCancel, 0); !done || result != nil {
+		t.Errorf("Expected cancel to close without a result, got %+v", result)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		input string
+		key   Key
+		size  int
+	}{
+		{"\r", KeyEnter, 1},
+		{"\x7f", KeyBackspace, 1},
+		{"\x1b", KeyCancel, 1},
+		{"\x1b[A", KeyUp, 3},
+		{"\x1bOB", KeyDown, 3},
+		{"\x1b[3~", KeyUnknown, 4},
+		{"\x18", KeyRemove, 1},
+		{"é", KeyRune, 2},
+	}
+
+	for _, tt := range tests {
+		key, _, size := parseKey([]byte(tt.input))
+		if key != tt.key || size != tt.size {
+			t.Errorf("parseKey(%q) = %v, %d, expected %v, %d", tt.input, key, size, tt.key, tt.size)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	p := New([]Item{{Text: "feature/a-very-long-branch-name", Marks: "●", Detail: "/some/long/path"}})
+
+	var buf bytes.Buffer
+	p.render(&buf, 30)
+
+	for _, line := range strings.Split(buf.String(), "\r\n")[1:] {
+		if strings.Contains(line, "/some/long/path") {
+			t.Errorf("Expected detail to be truncated to the terminal width: %q", line)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package picker
+
+import "fmt"
+
+func makeRaw(fd int) (func(), error) {
+	return nil, fmt.Errorf("the interactive picker is not supported on this platform")
+}
+
+func terminalWidth(fd int) int {
+	return 80
+}
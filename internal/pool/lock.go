@@ -0,0 +1,169 @@
+package pool
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mskelton/pool/internal/errors"
+)
+
+const (
+	LockFileName       = "pool.lock"
+	DefaultLockTimeout = 10 * time.Second
+
+	lockRetryInterval = 50 * time.Millisecond
+	// lockWriteGrace is how long a lock file may stay unparseable while its
+	// holder writes it. Older ones were left behind by a crash.
+	lockWriteGrace = 2 * time.Second
+)
+
+// Lock is an advisory lock on the pool status file. It is held by creating
+// the lock file exclusively and released by removing it.
+type Lock struct {
+	path string
+}
+
+// LockHolder describes the process that currently holds a lock.
+type LockHolder struct {
+	PID      int       `json:"pid"`
+	Hostname string    `json:"hostname"`
+	Acquired time.Time `json:"acquired"`
+}
+
+type LockError struct {
+	Path   string
+	Holder *LockHolder
+}
+
+func (e *LockError) Error() string {
+	if e.Holder == nil {
+		return fmt.Sprintf("worktree pool is locked (lock file: %s)", e.Path)
+	}
+
+	return fmt.Sprintf(
+		"worktree pool is locked by pid %d on %s since %s; wait for the other pool command to finish, or remove %s if it is no longer running",
+		e.Holder.PID,
+		e.Holder.Hostname,
+		e.Holder.Acquired.Format(time.RFC3339),
+		e.Path,
+	)
+}
+
+func (e *LockError) Unwrap() error {
+	return errors.ErrPoolLocked
+}
+
+// AcquireLock takes the lock at path, waiting up to timeout for another
+// process to release it. Locks held by processes that no longer exist on
+// this host are removed, and so are lock files that were never completely
+// written.
+func AcquireLock(path string, timeout time.Duration) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, errors.Wrap(err, "failed to create lock directory")
+	}
+
+	hostname, _ := os.Hostname()
+	data, err := json.Marshal(LockHolder{
+		PID:      os.Getpid(),
+		Hostname: hostname,
+		Acquired: time.Now(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal lock")
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = file.Write(data)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				return nil, errors.Wrap(err, "failed to write lock file")
+			}
+			return &Lock{path: path}, nil
+		}
+
+		if !os.IsExist(err) {
+			return nil, errors.Wrap(err, "failed to create lock file")
+		}
+
+		holder, raw, err := readLock(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		if holder != nil && holder.isStale(hostname) {
+			removeStaleLock(path, raw, time.Time{})
+			continue
+		}
+
+		if holder == nil {
+			if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > lockWriteGrace {
+				removeStaleLock(path, raw, info.ModTime())
+				continue
+			}
+		}
+
+		if !time.Now().Before(deadline) {
+			return nil, &LockError{Path: path, Holder: holder}
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// Release removes the lock file. It is safe to call if the lock file has
+// already been removed along with the pool directory.
+func (l *Lock) Release() error {
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to remove lock file")
+	}
+	return nil
+}
+
+func (h *LockHolder) isStale(hostname string) bool {
+	return h.Hostname == hostname && !ProcessAlive(h.PID)
+}
+
+func readLock(path string) (*LockHolder, []byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var holder LockHolder
+	if err := json.Unmarshal(data, &holder); err != nil {
+		// The holder may still be writing the file
+		return nil, data, nil
+	}
+
+	return &holder, data, nil
+}
+
+// removeStaleLock removes the lock file if it still has the stale contents,
+// and the modification time modTime unless that is zero.
+func removeStaleLock(path string, stale []byte, modTime time.Time) {
+	// Only remove the file if it wasn't replaced by a live process since we
+	// read it.
+	current, err := os.ReadFile(path)
+	if err != nil || !bytes.Equal(current, stale) {
+		return
+	}
+
+	if !modTime.IsZero() {
+		if info, err := os.Stat(path); err != nil || !info.ModTime().Equal(modTime) {
+			return
+		}
+	}
+
+	os.Remove(path)
+}
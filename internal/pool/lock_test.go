@@ -0,0 +1,138 @@
+package pool
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mskelton/pool/internal/errors"
+)
+
+func TestLock(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "lock-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	lockPath := filepath.Join(tmpDir, LockFileName)
+
+	lock, err := AcquireLock(lockPath, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = AcquireLock(lockPath, 100*time.Millisecond)
+	if !errors.Is(err, errors.ErrPoolLocked) {
+		t.Fatalf("Expected lock to be held, got %v", err)
+	}
+
+	var lockErr *LockError
+	if !errors.As(err, &lockErr) || lockErr.Holder == nil || lockErr.Holder.PID != os.Getpid() {
+		t.Errorf("Expected lock error to report the holder pid, got %v", err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Error("Expected lock file to be removed on release")
+	}
+
+	lock, err = AcquireLock(lockPath, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected lock to be acquired after release, got %v", err)
+	}
+	lock.Release()
+}
+
+func TestStaleLock(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "lock-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	lockPath := filepath.Join(tmpDir, LockFileName)
+	hostname, _ := os.Hostname()
+
+	data, err := json.Marshal(LockHolder{
+		PID:      deadPID(t),
+		Hostname: hostname,
+		Acquired: time.Now().Add(-time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(lockPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := AcquireLock(lockPath, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected stale lock to be replaced, got %v", err)
+	}
+	defer lock.Release()
+
+	holder, _, err := readLock(lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if holder.PID != os.Getpid() {
+		t.Errorf("Expected lock to be held by %d, got %d", os.Getpid(), holder.PID)
+	}
+}
+
+func TestUnparseableLock(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "lock-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	lockPath := filepath.Join(tmpDir, LockFileName)
+
+	if err := os.WriteFile(lockPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := AcquireLock(lockPath, 100*time.Millisecond); !errors.Is(err, errors.ErrPoolLocked) {
+		t.Fatalf("Expected an empty lock file being written to be respected, got %v", err)
+	}
+
+	old := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := AcquireLock(lockPath, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected empty lock file left by a crash to be replaced, got %v", err)
+	}
+	defer lock.Release()
+
+	holder, _, err := readLock(lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if holder == nil || holder.PID != os.Getpid() {
+		t.Errorf("Expected lock to be held by %d, got %+v", os.Getpid(), holder)
+	}
+}
+
+func deadPID(t *testing.T) int {
+	for pid := 999999; pid > 900000; pid-- {
+		if !ProcessAlive(pid) {
+			return pid
+		}
+	}
+
+	t.Skip("no unused pid found")
+	return 0
+}
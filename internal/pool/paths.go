@@ -0,0 +1,108 @@
+package pool
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PathRecord is where the worktree for a branch was created, and the pool
+// entry it was claimed from, if any.
+type PathRecord struct {
+	Path      string    `json:"path"`
+	Entry     string    `json:"entry,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// UsedAt is the last time pool switched into the existing worktree.
+	UsedAt time.Time `json:"used_at,omitempty"`
+}
+
+// LastUsed returns the last time pool switched into the worktree, or created
+// it.
+func (r *PathRecord) LastUsed() time.Time {
+	if r.UsedAt.After(r.CreatedAt) {
+		return r.UsedAt
+	}
+	return r.CreatedAt
+}
+
+// UnmarshalJSON also accepts a bare path, which is how records were stored
+// before they included the pool entry.
+func (r *PathRecord) UnmarshalJSON(data []byte) error {
+	var path string
+	if err := json.Unmarshal(data, &path); err == nil {
+		*r = PathRecord{Path: path}
+		return nil
+	}
+
+	type record PathRecord
+	return json.Unmarshal(data, (*record)(r))
+}
+
+// RecordPath remembers where the worktree for branch was created, so that
+// later lookups don't depend on the worktree_path template in effect. entry
+// is the pool entry the worktree was claimed from, or empty if it was
+// created directly.
+func (m *Manager) RecordPath(branch, path, entry string) error {
+	return m.WithLock(func() error {
+		if m.Status.Paths == nil {
+			m.Status.Paths = make(map[string]*PathRecord)
+		}
+		m.Status.Paths[branch] = &PathRecord{Path: path, Entry: entry, CreatedAt: time.Now()}
+		return m.save()
+	})
+}
+
+// TouchPath records that pool switched into the existing worktree at path for
+// branch.
+func (m *Manager) TouchPath(branch, path string) error {
+	return m.WithLock(func() error {
+		if m.Status.Paths == nil {
+			m.Status.Paths = make(map[string]*PathRecord)
+		}
+
+		record, ok := m.Status.Paths[branch]
+		if !ok || record.Path != path {
+			record = &PathRecord{Path: path}
+			m.Status.Paths[branch] = record
+		}
+		record.UsedAt = time.Now()
+
+		return m.save()
+	})
+}
+
+// ForgetPath drops the recorded worktree path for branch.
+func (m *Manager) ForgetPath(branch string) error {
+	if _, ok := m.Status.Paths[branch]; !ok {
+		return nil
+	}
+
+	return m.WithLock(func() error {
+		delete(m.Status.Paths, branch)
+		return m.save()
+	})
+}
+
+// LookupPath returns the recorded worktree path for branch.
+func (m *Manager) LookupPath(branch string) (string, bool) {
+	record, ok := m.Status.Paths[branch]
+	if !ok {
+		return "", false
+	}
+	return record.Path, true
+}
+
+// LookupRecord returns everything recorded about the worktree for branch.
+func (m *Manager) LookupRecord(branch string) (*PathRecord, bool) {
+	record, ok := m.Status.Paths[branch]
+	return record, ok
+}
+
+// BranchAt returns the branch whose worktree was recorded at path.
+func (m *Manager) BranchAt(path string) (string, bool) {
+	for branch, record := range m.Status.Paths {
+		if record.Path == path {
+			return branch, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,85 @@
+package pool
+
+import (
+	"os"
+	"testing"
+
+	"github.com/mskelton/pool/internal/git"
+)
+
+func TestRecordPath(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "paths-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := initTestRepo(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := git.NewRepository(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := NewManager(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := manager.RecordPath("feat/login", "/worktrees/feat-login", "pool-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := NewManager(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if path, ok := reloaded.LookupPath("feat/login"); !ok || path != "/worktrees/feat-login" {
+		t.Errorf("Expected recorded path /worktrees/feat-login, got %q", path)
+	}
+
+	if record, ok := reloaded.LookupRecord("feat/login"); !ok || record.Entry != "pool-1" {
+		t.Errorf("Expected feat/login to be claimed from pool-1, got %+v", record)
+	}
+
+	if branch, ok := reloaded.BranchAt("/worktrees/feat-login"); !ok || branch != "feat/login" {
+		t.Errorf("Expected feat/login at /worktrees/feat-login, got %q", branch)
+	}
+
+	created := record(t, reloaded, "feat/login").LastUsed()
+	if err := reloaded.TouchPath("feat/login", "/worktrees/feat-login"); err != nil {
+		t.Fatal(err)
+	}
+
+	if used := record(t, reloaded, "feat/login"); used.Entry != "pool-1" || !used.LastUsed().After(created) {
+		t.Errorf("Expected switching into feat/login to update its last use, got %+v", used)
+	}
+
+	if err := reloaded.TouchPath("feat/other", "/worktrees/feat-other"); err != nil {
+		t.Fatal(err)
+	}
+
+	if other := record(t, reloaded, "feat/other"); other.Path != "/worktrees/feat-other" || other.LastUsed().IsZero() {
+		t.Errorf("Expected switching into feat/other to record it, got %+v", other)
+	}
+
+	if err := reloaded.ForgetPath("feat/login"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := reloaded.LookupPath("feat/login"); ok {
+		t.Error("Expected path to be forgotten")
+	}
+}
+
+func record(t *testing.T, manager *Manager, branch string) *PathRecord {
+	t.Helper()
+	record, ok := manager.LookupRecord(branch)
+	if !ok {
+		t.Fatalf("Expected a record for %s", branch)
+	}
+	return record
+}
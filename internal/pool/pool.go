@@ -0,0 +1,481 @@
+package pool
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/mskelton/pool/internal/config"
+	"github.com/mskelton/pool/internal/errors"
+	"github.com/mskelton/pool/internal/git"
+	"github.com/mskelton/pool/internal/hooks"
+	"github.com/mskelton/pool/internal/logger"
+	"github.com/mskelton/pool/internal/progress"
+)
+
+const (
+	PoolDir         = ".worktree-pool"
+	StatusFileName  = "status.json"
+	NamePrefix      = "pool-"
+	DefaultPoolSize = 5
+)
+
+type WorktreeStatus string
+
+const (
+	StatusAvailable WorktreeStatus = "available"
+	StatusInUse     WorktreeStatus = "in-use"
+	StatusCreating  WorktreeStatus = "creating"
+)
+
+type Status struct {
+	Worktrees map[string]WorktreeStatus `json:"worktrees"`
+	Claims    map[string]*Claim         `json:"claims,omitempty"`
+	Warmups   map[string][]WarmupRecord `json:"warmups,omitempty"`
+	Refreshed map[string]*RefreshRecord `json:"refreshed,omitempty"`
+	Paths     map[string]*PathRecord    `json:"paths,omitempty"`
+}
+
+// Claim records which process owns a pool worktree that is in use or being
+// created, so that entries leaked by a crashed process can be reclaimed.
+type Claim struct {
+	PID       int       `json:"pid"`
+	Hostname  string    `json:"hostname"`
+	StartedAt time.Time `json:"started_at"`
+	Branch    string    `json:"branch,omitempty"`
+}
+
+func newClaim(branch string) *Claim {
+	hostname, _ := os.Hostname()
+	return &Claim{
+		PID:       os.Getpid(),
+		Hostname:  hostname,
+		StartedAt: time.Now(),
+		Branch:    branch,
+	}
+}
+
+type Manager struct {
+	Status      *Status
+	LockTimeout time.Duration
+	Warmup      []config.WarmupStep
+	Hooks       *hooks.Runner
+
+	// RefreshOnRefill refreshes available worktrees after each RunRefill.
+	RefreshOnRefill bool
+	Offline         bool
+	Preserve        []string
+
+	repo *git.Repository
+	path string
+	// daemon is set once the process holds the refill lock as the daemon.
+	daemon bool
+}
+
+func NewManager(repo *git.Repository) (*Manager, error) {
+	topLevel, err := repo.GetTopLevel()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{
+		LockTimeout: DefaultLockTimeout,
+		repo:        repo,
+		path:        filepath.Join(topLevel, PoolDir),
+	}
+
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+
+	if m.hasLeakedClaims() {
+		if err := m.Reconcile(); err != nil {
+			logger.Warning("Failed to reclaim leaked pool worktrees: %v", err)
+		}
+	}
+
+	return m, nil
+}
+
+func (m *Manager) Path() string {
+	return m.path
+}
+
+func (m *Manager) WorktreePath(name string) string {
+	return filepath.Join(m.path, name)
+}
+
+// WithLock runs fn while holding the pool lock. The status is reloaded from
+// disk before fn runs so that fn sees changes made by other processes.
+func (m *Manager) WithLock(fn func() error) error {
+	lock, err := AcquireLock(filepath.Join(m.path, LockFileName), m.LockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	if err := m.load(); err != nil {
+		return err
+	}
+
+	return fn()
+}
+
+func (m *Manager) Initialize(size int) error {
+	if size < 1 {
+		size = DefaultPoolSize
+	}
+
+	logger.Info("Initializing worktree pool with %d worktrees...", size)
+
+	if err := m.fill(size); err != nil {
+		return err
+	}
+
+	logger.Success("Worktree pool initialized at %s", m.path)
+	return nil
+}
+
+func (m *Manager) Refill(size int) error {
+	if size < 1 {
+		size = DefaultPoolSize
+	}
+
+	return m.fill(size)
+}
+
+// GetAvailable returns the first available pool worktree without claiming
+// it. Use Claim to atomically find and mark a worktree as in use.
+func (m *Manager) GetAvailable() (string, string, error) {
+	for _, name := range m.names() {
+		if m.Status.Worktrees[name] != StatusAvailable {
+			continue
+		}
+
+		path := m.WorktreePath(name)
+		if _, err := os.Stat(path); err == nil {
+			return path, name, nil
+		}
+	}
+
+	return "", "", errors.ErrNoPoolAvailable
+}
+
+// Claim atomically finds an available pool worktree and marks it as in use
+// by the current process for branch.
+func (m *Manager) Claim(branch string) (string, string, error) {
+	var path, name string
+
+	err := m.WithLock(func() error {
+		reclaimed := m.reconcile()
+
+		var err error
+		path, name, err = m.GetAvailable()
+		if err != nil {
+			if reclaimed {
+				m.save()
+			}
+			return err
+		}
+
+		m.setStatus(name, StatusInUse, newClaim(branch))
+		return m.save()
+	})
+
+	return path, name, err
+}
+
+func (m *Manager) MarkInUse(name string) error {
+	return m.WithLock(func() error {
+		if m.Status.Worktrees[name] != StatusAvailable {
+			return fmt.Errorf("pool worktree %s is not available", name)
+		}
+
+		m.setStatus(name, StatusInUse, newClaim(""))
+		return m.save()
+	})
+}
+
+func (m *Manager) MarkAvailable(name string) error {
+	return m.WithLock(func() error {
+		m.setStatus(name, StatusAvailable, nil)
+		return m.save()
+	})
+}
+
+// Remove drops a pool entry, e.g. once a claimed worktree has been moved out
+// of the pool.
+func (m *Manager) Remove(name string) error {
+	return m.WithLock(func() error {
+		m.removeEntry(name)
+		return m.save()
+	})
+}
+
+// Return resets the worktree at path to the default branch, removes
+// untracked and ignored files other than those matching Preserve, and moves
+// it back into the pool as an available entry, returning the entry's name.
+// Callers must make sure nothing of value is left in the worktree first.
+func (m *Manager) Return(path string) (string, error) {
+	if err := m.resetWorktree(path); err != nil {
+		return "", errors.Wrap(err, "failed to reset worktree")
+	}
+
+	if err := os.MkdirAll(m.path, 0755); err != nil {
+		return "", errors.Wrap(err, "failed to create pool directory")
+	}
+
+	var name string
+	err := m.WithLock(func() error {
+		name = m.reserve(1)[0]
+		return m.save()
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := m.repo.MoveWorktree(path, m.WorktreePath(name)); errors.Is(err, errors.ErrCrossDevice) {
+		// Replace it with a fresh pool worktree instead.
+		if err := m.repo.ForceRemoveWorktree(path); err != nil {
+			m.Remove(name)
+			return "", err
+		}
+
+		if err := m.repo.AddDetachedWorktree(m.WorktreePath(name), m.repo.DefaultBranchRef()); err != nil {
+			m.Remove(name)
+			return "", err
+		}
+	} else if err != nil {
+		m.Remove(name)
+		return "", err
+	}
+
+	err = m.WithLock(func() error {
+		m.setStatus(name, StatusAvailable, nil)
+		return m.save()
+	})
+
+	return name, err
+}
+
+func (m *Manager) GetStatus() (int, int) {
+	available := 0
+	for _, status := range m.Status.Worktrees {
+		if status == StatusAvailable {
+			available++
+		}
+	}
+
+	return len(m.Status.Worktrees), available
+}
+
+// fill creates worktrees until the pool holds size entries. Names are
+// reserved under the lock, but the worktrees themselves are created without
+// it so that claims are not blocked while git checks out files.
+func (m *Manager) fill(size int) error {
+	var names []string
+
+	err := m.WithLock(func() error {
+		m.reconcile()
+		m.prune()
+		names = m.reserve(size - len(m.Status.Worktrees))
+		return m.save()
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(names) == 0 {
+		logger.Info("Pool is already full (%d worktrees)", size)
+		return nil
+	}
+
+	bar := progress.NewProgressBar(len(names), "Creating pool worktrees")
+	created := make(map[string]bool, len(names))
+
+	var createErr error
+	for _, name := range names {
+		if err := m.repo.AddDetachedWorktree(m.WorktreePath(name), m.repo.DefaultBranchRef()); err != nil {
+			createErr = errors.Wrapf(err, "failed to create pool worktree %s", name)
+			break
+		}
+
+		created[name] = true
+		bar.Increment()
+	}
+	bar.Complete()
+
+	warmups := make(map[string][]WarmupRecord)
+	if len(m.Warmup) > 0 {
+		for _, name := range names {
+			if created[name] {
+				warmups[name] = m.warmup(m.WorktreePath(name), nil)
+			}
+		}
+	}
+
+	err = m.WithLock(func() error {
+		for _, name := range names {
+			if created[name] {
+				m.setStatus(name, StatusAvailable, nil)
+				m.setWarmups(name, warmups[name])
+			} else {
+				m.removeEntry(name)
+			}
+		}
+		return m.save()
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Worktrees created before a failure are in the pool, so they get the
+	// hook too.
+	for _, name := range names {
+		if !created[name] {
+			continue
+		}
+
+		env := hooks.Env{
+			WorktreePath: m.WorktreePath(name),
+			Entry:        name,
+			RepoRoot:     filepath.Dir(m.path),
+		}
+
+		if err := m.Hooks.Run(hooks.PostRefill, env); err != nil {
+			logger.Warning("%v", err)
+		}
+	}
+
+	return createErr
+}
+
+// prune drops entries whose worktree no longer exists in the pool directory,
+// which is the case for worktrees that have been claimed and moved out.
+func (m *Manager) prune() {
+	for name, status := range m.Status.Worktrees {
+		if status == StatusCreating {
+			continue
+		}
+
+		if _, err := os.Stat(m.WorktreePath(name)); os.IsNotExist(err) {
+			m.removeEntry(name)
+		}
+	}
+}
+
+func (m *Manager) reserve(count int) []string {
+	var names []string
+
+	for i := 1; len(names) < count; i++ {
+		name := fmt.Sprintf("%s%d", NamePrefix, i)
+		if _, ok := m.Status.Worktrees[name]; ok {
+			continue
+		}
+
+		if _, err := os.Stat(m.WorktreePath(name)); err == nil {
+			continue
+		}
+
+		m.setStatus(name, StatusCreating, newClaim(""))
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func (m *Manager) setStatus(name string, status WorktreeStatus, claim *Claim) {
+	m.Status.Worktrees[name] = status
+
+	if claim == nil {
+		delete(m.Status.Claims, name)
+		return
+	}
+
+	if m.Status.Claims == nil {
+		m.Status.Claims = make(map[string]*Claim)
+	}
+	m.Status.Claims[name] = claim
+}
+
+func (m *Manager) setWarmups(name string, records []WarmupRecord) {
+	if len(records) == 0 {
+		delete(m.Status.Warmups, name)
+		return
+	}
+
+	if m.Status.Warmups == nil {
+		m.Status.Warmups = make(map[string][]WarmupRecord)
+	}
+	m.Status.Warmups[name] = records
+}
+
+func (m *Manager) removeEntry(name string) {
+	delete(m.Status.Worktrees, name)
+	delete(m.Status.Claims, name)
+	delete(m.Status.Warmups, name)
+	delete(m.Status.Refreshed, name)
+}
+
+func (m *Manager) names() []string {
+	names := make([]string, 0, len(m.Status.Worktrees))
+	for name := range m.Status.Worktrees {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func (m *Manager) load() error {
+	m.Status = &Status{Worktrees: make(map[string]WorktreeStatus)}
+
+	data, err := os.ReadFile(filepath.Join(m.path, StatusFileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to read pool status")
+	}
+
+	if err := json.Unmarshal(data, m.Status); err != nil {
+		return errors.Wrap(err, "invalid pool status file")
+	}
+
+	if m.Status.Worktrees == nil {
+		m.Status.Worktrees = make(map[string]WorktreeStatus)
+	}
+
+	return nil
+}
+
+// save writes the status file atomically so that a crash mid-write never
+// leaves a truncated file behind.
+func (m *Manager) save() error {
+	data, err := json.MarshalIndent(m.Status, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal pool status")
+	}
+
+	if err := os.MkdirAll(m.path, 0755); err != nil {
+		return errors.Wrap(err, "failed to create pool directory")
+	}
+
+	path := filepath.Join(m.path, StatusFileName)
+	tmp := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return errors.Wrap(err, "failed to write pool status")
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return errors.Wrap(err, "failed to write pool status")
+	}
+
+	return nil
+}
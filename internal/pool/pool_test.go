@@ -0,0 +1,254 @@
+package pool
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/mskelton/pool/internal/errors"
+	"github.com/mskelton/pool/internal/git"
+	"github.com/mskelton/pool/internal/hooks"
+)
+
+func TestClaim(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "pool-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := initTestRepo(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := git.NewRepository(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := NewManager(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := manager.Initialize(2); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	names := make([]string, 3)
+	errs := make([]error, 3)
+
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			m, err := NewManager(repo)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+
+			_, names[i], errs[i] = m.Claim("feature")
+		}(i)
+	}
+	wg.Wait()
+
+	claimed := make(map[string]bool)
+	failures := 0
+	for i, err := range errs {
+		if errors.Is(err, errors.ErrNoPoolAvailable) {
+			failures++
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if claimed[names[i]] {
+			t.Errorf("Pool worktree %s was claimed twice", names[i])
+		}
+		claimed[names[i]] = true
+	}
+
+	if len(claimed) != 2 || failures != 1 {
+		t.Errorf("Expected 2 claims and 1 failure, got %d claims and %d failures", len(claimed), failures)
+	}
+
+	manager, err = NewManager(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, available := manager.GetStatus(); available != 0 {
+		t.Errorf("Expected 0 available worktrees, got %d", available)
+	}
+}
+
+func TestReturn(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "return-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := initTestRepo(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := git.NewRepository(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := NewManager(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	worktreePath := filepath.Join(tmpDir, "review")
+	if err := repo.AddWorktree(worktreePath, "review"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(worktreePath, "scratch.txt"), []byte("scratch"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, ".git", "info", "exclude"), []byte(".cache\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(worktreePath, ".cache"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(worktreePath, ".cache", "build"), []byte("cached"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager.Preserve = []string{".cache"}
+
+	name, err := manager.Return(worktreePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if manager.Status.Worktrees[name] != StatusAvailable {
+		t.Errorf("Expected %s to be available, got %s", name, manager.Status.Worktrees[name])
+	}
+
+	if _, err := os.Stat(worktreePath); !os.IsNotExist(err) {
+		t.Error("Expected worktree to be moved into the pool")
+	}
+
+	if _, err := os.Stat(filepath.Join(manager.WorktreePath(name), "scratch.txt")); !os.IsNotExist(err) {
+		t.Error("Expected untracked files to be cleaned")
+	}
+
+	if _, err := os.Stat(filepath.Join(manager.WorktreePath(name), ".cache", "build")); err != nil {
+		t.Error("Expected preserved files to be kept")
+	}
+
+	if git.IsDirty(manager.WorktreePath(name)) {
+		t.Error("Expected returned worktree to be clean")
+	}
+
+	if !repo.BranchExists("review") {
+		t.Error("Expected the branch to be kept")
+	}
+}
+
+func initTestRepo(dir string) error {
+	cmd := exec.Command("git", "init")
+	cmd.Dir = dir
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+
+	cmd = exec.Command("git", "config", "user.email", "test@example.com")
+	cmd.Dir = dir
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+
+	cmd = exec.Command("git", "config", "user.name", "Test User")
+	cmd.Dir = dir
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+
+	testFile := filepath.Join(dir, "README.md")
+	if err := os.WriteFile(testFile, []byte("# Test Repo"), 0644); err != nil {
+		return err
+	}
+
+	cmd = exec.Command("git", "add", ".")
+	cmd.Dir = dir
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+
+	cmd = exec.Command("git", "commit", "-m", "Initial commit")
+	cmd.Dir = dir
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+
+	cmd = exec.Command("git", "branch", "-M", "main")
+	cmd.Dir = dir
+	cmd.Run()
+
+	return nil
+}
+
+func TestRefillHookSkipsFailedWorktrees(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "pool-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := initTestRepo(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := git.NewRepository(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := NewManager(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A missing but still registered worktree makes creating pool-2 fail.
+	blocked := manager.WorktreePath("pool-2")
+	if err := repo.AddDetachedWorktree(blocked, repo.DefaultBranch); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(blocked); err != nil {
+		t.Fatal(err)
+	}
+
+	logFile := filepath.Join(tmpDir, "refilled.log")
+	manager.Hooks = &hooks.Runner{Commands: map[string][]string{
+		string(hooks.PostRefill): {`echo "$POOL_ENTRY" >> ` + logFile},
+	}}
+
+	if err := manager.Refill(3); err == nil {
+		t.Fatal("Expected refill to fail")
+	}
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "pool-1\n" {
+		t.Errorf("Expected the hook to run only for pool-1, got %q", data)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build windows
+
+package pool
+
+import "os"
+
+// ProcessAlive reports whether a process with the given pid exists.
+func ProcessAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	process.Release()
+	return true
+}
@@ -0,0 +1,129 @@
+package pool
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/mskelton/pool/internal/git"
+	"github.com/mskelton/pool/internal/logger"
+)
+
+// Reconcile returns pool entries leaked by processes that exited mid-claim
+// to a consistent state.
+func (m *Manager) Reconcile() error {
+	return m.WithLock(func() error {
+		if !m.reconcile() {
+			return nil
+		}
+		return m.save()
+	})
+}
+
+func (m *Manager) hasLeakedClaims() bool {
+	for name, claim := range m.Status.Claims {
+		if m.Status.Worktrees[name] != StatusAvailable && !claim.ownerAlive() {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcile checks every in-use or creating entry whose owner has exited
+// against git's worktree list. Entries still registered in the pool
+// directory are reset and made available again, partially created ones are
+// removed, and entries that were moved out of the pool are dropped. It
+// reports whether the status changed.
+func (m *Manager) reconcile() bool {
+	if !m.hasLeakedClaims() {
+		return false
+	}
+
+	worktrees, err := m.repo.ListWorktrees()
+	if err != nil {
+		logger.Warning("Failed to list worktrees: %v", err)
+		return false
+	}
+
+	registered := make(map[string]bool, len(worktrees))
+	for _, wt := range worktrees {
+		registered[canonicalPath(wt.Path)] = true
+	}
+
+	changed := false
+	for _, name := range m.names() {
+		claim := m.Status.Claims[name]
+		status := m.Status.Worktrees[name]
+		if claim == nil || status == StatusAvailable || claim.ownerAlive() {
+			continue
+		}
+
+		path := m.WorktreePath(name)
+		changed = true
+
+		switch {
+		case !registered[canonicalPath(path)]:
+			logger.Warning("Dropping pool worktree %s left behind by pid %d", name, claim.PID)
+			m.removeEntry(name)
+
+		case status == StatusCreating:
+			logger.Warning("Removing partially created pool worktree %s", name)
+			if err := m.repo.ForceRemoveWorktree(path); err != nil {
+				logger.Warning("Failed to remove %s: %v", path, err)
+			}
+			m.removeEntry(name)
+
+		default:
+			if err := m.resetWorktree(path); err != nil {
+				logger.Warning("Dropping pool worktree %s: %v", name, err)
+				m.removeEntry(name)
+				continue
+			}
+
+			logger.Info("Returned pool worktree %s to the pool (claimed by pid %d for %q)", name, claim.PID, claim.Branch)
+			m.setStatus(name, StatusAvailable, nil)
+		}
+	}
+
+	return changed
+}
+
+// resetWorktree detaches the worktree at path at the default branch,
+// discarding changes, and removes untracked and ignored files other than
+// those matching Preserve, so that it can be claimed again.
+func (m *Manager) resetWorktree(path string) error {
+	if err := git.RunInDir(path, "checkout", "--detach", "--force", m.repo.DefaultBranchRef()); err != nil {
+		return err
+	}
+
+	args := []string{"clean", "-fdx"}
+	for _, pattern := range m.Preserve {
+		args = append(args, "-e", pattern)
+	}
+
+	return git.RunInDir(path, args...)
+}
+
+// ClaimActive reports whether the pool worktree name is claimed by a process
+// that may still be running, e.g. one moving it out of the pool.
+func (m *Manager) ClaimActive(name string) bool {
+	claim := m.Status.Claims[name]
+	return claim != nil && claim.ownerAlive()
+}
+
+// ownerAlive reports whether the claiming process may still be running.
+// Claims made on another host cannot be checked and are assumed alive.
+func (c *Claim) ownerAlive() bool {
+	hostname, _ := os.Hostname()
+	if c.Hostname != hostname {
+		return true
+	}
+
+	return ProcessAlive(c.PID)
+}
+
+func canonicalPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
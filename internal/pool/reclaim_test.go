@@ -0,0 +1,71 @@
+package pool
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mskelton/pool/internal/git"
+)
+
+func TestReconcileLeakedClaims(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "reclaim-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := initTestRepo(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := git.NewRepository(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := NewManager(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := manager.Initialize(2); err != nil {
+		t.Fatal(err)
+	}
+
+	pid := deadPID(t)
+	leak := func(name string) {
+		claim := newClaim("feature-" + name)
+		claim.PID = pid
+		manager.setStatus(name, StatusInUse, claim)
+	}
+
+	// pool-1 was claimed but never moved, pool-2 was moved out of the pool
+	leak("pool-1")
+	leak("pool-2")
+	if err := manager.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	movedPath := filepath.Join(tmpDir, "moved")
+	if err := repo.MoveWorktree(manager.WorktreePath("pool-2"), movedPath); err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err = NewManager(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status := manager.Status.Worktrees["pool-1"]; status != StatusAvailable {
+		t.Errorf("Expected pool-1 to be available again, got %q", status)
+	}
+
+	if _, ok := manager.Status.Worktrees["pool-2"]; ok {
+		t.Error("Expected pool-2 to be dropped from the pool")
+	}
+
+	if len(manager.Status.Claims) != 0 {
+		t.Errorf("Expected no remaining claims, got %d", len(manager.Status.Claims))
+	}
+}
@@ -0,0 +1,191 @@
+package pool
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mskelton/pool/internal/errors"
+	"github.com/mskelton/pool/internal/logger"
+)
+
+const (
+	RefillPIDFileName   = "refill.pid"
+	RefillLogFileName   = "refill.log"
+	RefillStateFileName = "refill.json"
+
+	maxRefillLogSize = 1 << 20
+)
+
+// RefillState records the most recent refill run. Running and PID are filled
+// in from the refill pid file when a refiller is currently alive.
+type RefillState struct {
+	PID        int       `json:"pid"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at,omitzero"`
+	Error      string    `json:"error,omitempty"`
+	// Daemon is set when the refiller is pool daemon rather than a one-off
+	// refill.
+	Daemon  bool `json:"daemon,omitempty"`
+	Running bool `json:"-"`
+}
+
+func (m *Manager) LogPath() string {
+	return filepath.Join(m.path, RefillLogFileName)
+}
+
+// AcquireRefillLock marks the current process as the pool's refiller. It
+// fails immediately if another refiller is still running.
+func (m *Manager) AcquireRefillLock() (*Lock, error) {
+	return AcquireLock(filepath.Join(m.path, RefillPIDFileName), 0)
+}
+
+// AcquireDaemonLock marks the current process as the pool's refiller and
+// records it as the daemon, so that only the daemon is stopped by
+// pool daemon --stop.
+func (m *Manager) AcquireDaemonLock() (*Lock, error) {
+	lock, err := m.AcquireRefillLock()
+	if err != nil {
+		return nil, err
+	}
+
+	m.daemon = true
+	state := &RefillState{PID: os.Getpid(), StartedAt: time.Now(), Daemon: true}
+	if err := m.writeRefillState(state); err != nil {
+		lock.Release()
+		return nil, err
+	}
+
+	return lock, nil
+}
+
+// RecordDaemonExit marks the daemon's refill state as finished, so that a
+// stopped daemon isn't reported as an interrupted refill.
+func (m *Manager) RecordDaemonExit() error {
+	state, err := m.readRefillState()
+	if err != nil {
+		return err
+	}
+
+	if state == nil || state.PID != os.Getpid() {
+		state = &RefillState{PID: os.Getpid(), StartedAt: time.Now(), Daemon: true}
+	}
+	state.FinishedAt = time.Now()
+
+	return m.writeRefillState(state)
+}
+
+// RunRefill refills the pool and records the outcome so that it can be
+// reported by RefillStatus.
+func (m *Manager) RunRefill(size int) error {
+	state := &RefillState{
+		PID:       os.Getpid(),
+		StartedAt: time.Now(),
+		Daemon:    m.daemon,
+	}
+
+	logger.Info("Refill started at %s", state.StartedAt.Format(time.RFC3339))
+	if err := m.writeRefillState(state); err != nil {
+		return err
+	}
+
+	err := m.Refill(size)
+	if err == nil && m.RefreshOnRefill {
+		var results []RefreshResult
+		results, err = m.Refresh()
+		for _, result := range results {
+			if result.Err != nil {
+				logger.Warning("Failed to refresh %s: %v", result.Name, result.Err)
+			}
+		}
+	}
+
+	state.FinishedAt = time.Now()
+	if err != nil {
+		state.Error = err.Error()
+	}
+
+	if writeErr := m.writeRefillState(state); writeErr != nil && err == nil {
+		err = writeErr
+	}
+
+	return err
+}
+
+// RefillStatus returns the state of the last refill, or nil if the pool has
+// never been refilled in the background.
+func (m *Manager) RefillStatus() (*RefillState, error) {
+	state, err := m.readRefillState()
+	if err != nil {
+		return nil, err
+	}
+
+	holder, _, err := readLock(filepath.Join(m.path, RefillPIDFileName))
+	if err == nil && holder != nil && ProcessAlive(holder.PID) {
+		if state == nil || state.PID != holder.PID {
+			state = &RefillState{PID: holder.PID, StartedAt: holder.Acquired}
+		}
+		state.Running = true
+	}
+
+	return state, nil
+}
+
+// OpenLog opens the refill log for appending, truncating it first once it
+// grows past 1 MiB.
+func (m *Manager) OpenLog() (*os.File, error) {
+	if err := os.MkdirAll(m.path, 0755); err != nil {
+		return nil, errors.Wrap(err, "failed to create pool directory")
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if info, err := os.Stat(m.LogPath()); err == nil && info.Size() > maxRefillLogSize {
+		flags |= os.O_TRUNC
+	}
+
+	file, err := os.OpenFile(m.LogPath(), flags, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open refill log")
+	}
+
+	return file, nil
+}
+
+func (m *Manager) Reload() error {
+	return m.load()
+}
+
+func (m *Manager) readRefillState() (*RefillState, error) {
+	data, err := os.ReadFile(filepath.Join(m.path, RefillStateFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read refill state")
+	}
+
+	state := &RefillState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, errors.Wrap(err, "invalid refill state file")
+	}
+
+	return state, nil
+}
+
+func (m *Manager) writeRefillState(state *RefillState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal refill state")
+	}
+
+	if err := os.MkdirAll(m.path, 0755); err != nil {
+		return errors.Wrap(err, "failed to create pool directory")
+	}
+
+	if err := os.WriteFile(filepath.Join(m.path, RefillStateFileName), data, 0644); err != nil {
+		return errors.Wrap(err, "failed to write refill state")
+	}
+
+	return nil
+}
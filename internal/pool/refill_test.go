@@ -0,0 +1,148 @@
+package pool
+
+import (
+	"os"
+	"testing"
+
+	"github.com/mskelton/pool/internal/git"
+)
+
+func TestRunRefill(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "refill-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := initTestRepo(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := git.NewRepository(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := NewManager(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := manager.RefillStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != nil {
+		t.Fatalf("Expected no refill state before first refill, got %+v", state)
+	}
+
+	lock, err := manager.AcquireRefillLock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := manager.AcquireRefillLock(); err == nil {
+		t.Error("Expected second refill lock to fail")
+	}
+
+	if err := manager.RunRefill(2); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err = manager.RefillStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.Running || state.PID != os.Getpid() {
+		t.Errorf("Expected refill to be reported as running by %d, got %+v", os.Getpid(), state)
+	}
+
+	lock.Release()
+
+	state, err = manager.RefillStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Running {
+		t.Error("Expected refill to no longer be running")
+	}
+	if state.FinishedAt.IsZero() || state.Error != "" {
+		t.Errorf("Expected a successful finished refill, got %+v", state)
+	}
+
+	if total, _ := manager.GetStatus(); total != 2 {
+		t.Errorf("Expected 2 worktrees after refill, got %d", total)
+	}
+}
+
+func TestDaemonLock(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "refill-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := initTestRepo(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := git.NewRepository(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	daemon, err := NewManager(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := daemon.AcquireDaemonLock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := daemon.RefillStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.Running || !state.Daemon {
+		t.Errorf("Expected a running daemon before its first refill, got %+v", state)
+	}
+
+	if err := daemon.RunRefill(1); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err = daemon.RefillStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.Running || !state.Daemon {
+		t.Errorf("Expected the daemon to stay recorded after a refill, got %+v", state)
+	}
+
+	lock.Release()
+
+	manager, err := NewManager(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err = manager.AcquireRefillLock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Release()
+
+	if err := manager.RunRefill(1); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err = manager.RefillStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.Running || state.Daemon {
+		t.Errorf("Expected a one-off refill not to be recorded as the daemon, got %+v", state)
+	}
+}
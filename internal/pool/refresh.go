@@ -0,0 +1,134 @@
+package pool
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mskelton/pool/internal/git"
+	"github.com/mskelton/pool/internal/logger"
+)
+
+const StatusRefreshing WorktreeStatus = "refreshing"
+
+// RefreshRecord is the commit an available pool worktree was last moved to
+// by Refresh.
+type RefreshRecord struct {
+	Base        string    `json:"base"`
+	RefreshedAt time.Time `json:"refreshed_at"`
+}
+
+type RefreshResult struct {
+	Name    string
+	Base    string
+	Action  string
+	Skipped string
+	Err     error
+}
+
+// RefreshTarget returns the ref pool worktrees are refreshed to.
+func (m *Manager) RefreshTarget() string {
+	return m.repo.DefaultBranchRef()
+}
+
+// Refresh fetches once, unless offline, and moves every available pool
+// worktree to the tip of the default branch. Worktrees with local
+// modifications are skipped.
+// Entries are marked as refreshing while they are updated so they cannot be
+// claimed halfway through.
+func (m *Manager) Refresh() ([]RefreshResult, error) {
+	if !m.Offline && m.repo.HasRemote("origin") {
+		logger.Info("Fetching origin...")
+		if err := m.repo.FetchOrigin(); err != nil {
+			return nil, err
+		}
+	}
+
+	target := m.RefreshTarget()
+	base, err := m.repo.ResolveCommit(target)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve %s: %w", target, err)
+	}
+
+	var names []string
+	err = m.WithLock(func() error {
+		for _, name := range m.names() {
+			if m.Status.Worktrees[name] == StatusAvailable {
+				m.setStatus(name, StatusRefreshing, newClaim(""))
+				names = append(names, name)
+			}
+		}
+		return m.save()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]RefreshResult, 0, len(names))
+	warmups := make(map[string][]WarmupRecord)
+
+	for _, name := range names {
+		result := m.refreshWorktree(name, base)
+		if result.Err == nil && result.Skipped == "" && len(m.Warmup) > 0 {
+			warmups[name] = m.warmup(m.WorktreePath(name), m.Status.Warmups[name])
+		}
+		results = append(results, result)
+	}
+
+	err = m.WithLock(func() error {
+		for _, result := range results {
+			if _, ok := m.Status.Worktrees[result.Name]; !ok {
+				continue
+			}
+
+			m.setStatus(result.Name, StatusAvailable, nil)
+
+			if result.Err == nil && result.Skipped == "" {
+				m.setRefreshed(result.Name, &RefreshRecord{Base: base, RefreshedAt: time.Now()})
+				if records, ok := warmups[result.Name]; ok {
+					m.setWarmups(result.Name, records)
+				}
+			}
+		}
+		return m.save()
+	})
+
+	return results, err
+}
+
+func (m *Manager) refreshWorktree(name, base string) RefreshResult {
+	path := m.WorktreePath(name)
+	result := RefreshResult{Name: name, Base: base}
+
+	if git.IsDirty(path) {
+		result.Skipped = "has local modifications"
+		return result
+	}
+
+	output, err := git.OutputInDir(path, "rev-parse", "HEAD")
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	head := strings.TrimSpace(output)
+
+	switch {
+	case head == base:
+		result.Action = "already up to date"
+	case m.repo.IsAncestor(head, base):
+		result.Action = "fast-forwarded"
+		result.Err = git.RunInDir(path, "checkout", "--detach", base)
+	default:
+		result.Action = "reset"
+		result.Err = git.RunInDir(path, "reset", "--hard", base)
+	}
+
+	return result
+}
+
+func (m *Manager) setRefreshed(name string, record *RefreshRecord) {
+	if m.Status.Refreshed == nil {
+		m.Status.Refreshed = make(map[string]*RefreshRecord)
+	}
+	m.Status.Refreshed[name] = record
+}
@@ -0,0 +1,90 @@
+package pool
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mskelton/pool/internal/git"
+)
+
+func TestRefresh(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "refresh-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := initTestRepo(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := git.NewRepository(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := NewManager(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := manager.Initialize(2); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("git", "commit", "--allow-empty", "-m", "Second commit")
+	cmd.Dir = tmpDir
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	tip, err := repo.ResolveCommit("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dirty := filepath.Join(manager.WorktreePath("pool-2"), "README.md")
+	if err := os.WriteFile(dirty, []byte("local change"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := manager.Refresh()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+
+	if results[0].Err != nil || results[0].Action != "fast-forwarded" {
+		t.Errorf("Expected pool-1 to be fast-forwarded, got %+v", results[0])
+	}
+
+	if results[1].Skipped == "" {
+		t.Errorf("Expected dirty pool-2 to be skipped, got %+v", results[1])
+	}
+
+	head, err := git.OutputInDir(manager.WorktreePath("pool-1"), "rev-parse", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(head) != tip {
+		t.Errorf("Expected pool-1 at %s, got %s", tip, head)
+	}
+
+	if record := manager.Status.Refreshed["pool-1"]; record == nil || record.Base != tip {
+		t.Errorf("Expected refresh record for pool-1 at %s, got %+v", tip, record)
+	}
+
+	if _, ok := manager.Status.Refreshed["pool-2"]; ok {
+		t.Error("Expected no refresh record for skipped pool-2")
+	}
+
+	if _, available := manager.GetStatus(); available != 2 {
+		t.Errorf("Expected 2 available worktrees after refresh, got %d", available)
+	}
+}
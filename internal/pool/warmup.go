@@ -0,0 +1,105 @@
+package pool
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/mskelton/pool/internal/config"
+	"github.com/mskelton/pool/internal/hooks"
+	"github.com/mskelton/pool/internal/logger"
+)
+
+// WarmupRecord is the hash of a warm-up step's trigger file at the time the
+// step last ran in a pool worktree.
+type WarmupRecord struct {
+	When string `json:"when"`
+	Run  string `json:"run"`
+	Hash string `json:"hash"`
+}
+
+// WarmUpClaimed re-runs the warm-up steps whose trigger file changed since
+// the pool worktree was created, e.g. because the claimed branch has a
+// different package-lock.json.
+func (m *Manager) WarmUpClaimed(name, path string) {
+	if len(m.Warmup) == 0 {
+		return
+	}
+
+	m.warmup(path, m.Status.Warmups[name])
+}
+
+// WarmUp runs every warm-up step in path, regardless of earlier runs.
+func (m *Manager) WarmUp(path string) {
+	if len(m.Warmup) == 0 {
+		return
+	}
+
+	m.warmup(path, nil)
+}
+
+// warmup runs each step whose trigger file exists in path and differs from
+// the previous record, returning the records for the steps that are now up
+// to date. Failed steps are reported but do not fail the caller.
+func (m *Manager) warmup(path string, previous []WarmupRecord) []WarmupRecord {
+	var records []WarmupRecord
+
+	for _, step := range m.Warmup {
+		hash, err := hashFile(filepath.Join(path, step.When))
+		if err != nil {
+			continue
+		}
+
+		record := WarmupRecord{When: step.When, Run: step.Run, Hash: hash}
+
+		if containsRecord(previous, record) {
+			logger.Info("Skipping %q, %s is unchanged", step.Run, step.When)
+			records = append(records, record)
+			continue
+		}
+
+		logger.Info("Running %q in %s...", step.Run, path)
+		if err := runStep(path, step); err != nil {
+			logger.Warning("Warm-up step %q failed: %v", step.Run, err)
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func runStep(dir string, step config.WarmupStep) error {
+	cmd := hooks.ShellCommand(step.Run)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func containsRecord(records []WarmupRecord, record WarmupRecord) bool {
+	for _, r := range records {
+		if r == record {
+			return true
+		}
+	}
+	return false
+}
+
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
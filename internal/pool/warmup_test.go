@@ -0,0 +1,74 @@
+package pool
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mskelton/pool/internal/config"
+	"github.com/mskelton/pool/internal/git"
+)
+
+func TestWarmup(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "warmup-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := initTestRepo(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := git.NewRepository(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := NewManager(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager.Warmup = []config.WarmupStep{
+		{When: "README.md", Run: "echo ran >> warmup.log"},
+		{When: "missing.lock", Run: "echo ran >> missing.log"},
+	}
+
+	if err := manager.Initialize(1); err != nil {
+		t.Fatal(err)
+	}
+
+	path := manager.WorktreePath("pool-1")
+	runs := func() int {
+		data, _ := os.ReadFile(filepath.Join(path, "warmup.log"))
+		return strings.Count(string(data), "ran")
+	}
+
+	if runs() != 1 {
+		t.Fatalf("Expected warm-up to run once on creation, ran %d times", runs())
+	}
+
+	if _, err := os.Stat(filepath.Join(path, "missing.log")); !os.IsNotExist(err) {
+		t.Error("Expected step without a trigger file to be skipped")
+	}
+
+	if records := manager.Status.Warmups["pool-1"]; len(records) != 1 {
+		t.Fatalf("Expected 1 warm-up record, got %d", len(records))
+	}
+
+	manager.WarmUpClaimed("pool-1", path)
+	if runs() != 1 {
+		t.Errorf("Expected unchanged trigger file to skip warm-up, ran %d times", runs())
+	}
+
+	if err := os.WriteFile(filepath.Join(path, "README.md"), []byte("# Changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager.WarmUpClaimed("pool-1", path)
+	if runs() != 2 {
+		t.Errorf("Expected changed trigger file to re-run warm-up, ran %d times", runs())
+	}
+}